@@ -0,0 +1,61 @@
+package spacegame
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+type FlightAssist int
+
+const (
+	AssistNewtonian FlightAssist = iota // the ship keeps going however it was going, only the pilot changes that
+	AssistDrift                         // thrusting also cancels the ship's drift to the side
+	AssistFullStop                      // when the pilot lets go, the ship turns retrograde and brakes to a stop
+	flightAssists                       // how many there are, to cycle through them
+)
+
+func (fa FlightAssist) String() string {
+	switch fa {
+	case AssistDrift:
+		return "Assisted"
+	case AssistFullStop:
+		return "Full stop"
+	}
+	return "Newtonian"
+}
+
+// of the engine's thrust the maneuvering thrusters can put into cancelling drift
+const driftThrust = 0.5
+
+func (se *ShipEngine) Assist() FlightAssist {
+	return se.assist
+}
+
+func (se *ShipEngine) SetAssist(assist FlightAssist) {
+	se.assist = assist
+}
+
+// CycleAssist switches to the next flight assist mode
+func (se *ShipEngine) CycleAssist() {
+	se.assist = (se.assist + 1) % flightAssists
+}
+
+// counterDrift is how much the maneuvering thrusters change velocity in dt seconds, against its sideways part
+func (se *ShipEngine) counterDrift(velocity pixel.Vec, dt float64) pixel.Vec {
+	// ships point up at angle 0
+	side := pixel.V(1, 0).Rotated(se.ship.angle)
+	drift := velocity.Dot(side)
+	change := math.Min(math.Abs(drift), se.Thrust*driftThrust*dt/se.ship.mass)
+	return side.Scaled(-math.Copysign(change, drift))
+}
+
+// fullStop brakes the ship for dt seconds unless the pilot or the autopilot is flying it
+func (se *ShipEngine) fullStop(dt float64) {
+	if se.assist != AssistFullStop || se.autopilot.Mode() != AutopilotOff || se.thrust > 0 || se.turned {
+		return
+	}
+	for _, command := range se.autopilot.steer(pixel.ZV, dt) {
+		se.steer(command)
+	}
+}
@@ -0,0 +1,221 @@
+package spacegame
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	asteroidChunk  = 512  // pixels, fields are made up and streamed in squares this big
+	streamRange    = 2000 // pixels around the player that asteroids are streamed in
+	despawnRange   = 3000 // pixels, asteroids further than this from the player are dropped again
+	asteroidPoints = 9    // corners of the biggest asteroids, small ones have fewer
+)
+
+// An AsteroidFieldConfig says where a system's asteroids are, the asteroids themselves are made up from its seed
+type AsteroidFieldConfig struct {
+	Name    string
+	Region  pixel.Rect
+	Density float64 // asteroids per million square pixels
+	MinSize float64 // radius in pixels
+	MaxSize float64
+	Seed    int64
+}
+
+// An AsteroidField makes up the asteroids of its region a chunk at a time,
+// the same ones every time, and keeps the chunks that are near the player
+type AsteroidField struct {
+	config AsteroidFieldConfig
+	chunks map[chunk][]*Asteroid
+}
+
+type chunk struct {
+	x, y int
+}
+
+func NewAsteroidField(config AsteroidFieldConfig) *AsteroidField {
+	if config.MinSize <= 0 {
+		config.MinSize = 8
+	}
+	if config.MaxSize < config.MinSize {
+		config.MaxSize = config.MinSize
+	}
+	return &AsteroidField{
+		config: config,
+		chunks: make(map[chunk][]*Asteroid),
+	}
+}
+
+func (af *AsteroidField) Config() AsteroidFieldConfig {
+	return af.config
+}
+
+// Stream makes up the chunks that came within range of center, and drops the ones that went out of it
+func (af *AsteroidField) Stream(center pixel.Vec) (spawned, despawned []*Asteroid) {
+	for _, c := range af.streamed() {
+		if c.rect().Center().Sub(center).Len() > despawnRange {
+			despawned = append(despawned, af.chunks[c]...)
+			delete(af.chunks, c)
+		}
+	}
+
+	near := pixel.R(center.X-streamRange, center.Y-streamRange, center.X+streamRange, center.Y+streamRange)
+	near = near.Intersect(af.config.Region)
+	if near.Area() == 0 {
+		return spawned, despawned
+	}
+	for x := chunkOf(near.Min.X); x <= chunkOf(near.Max.X); x++ {
+		for y := chunkOf(near.Min.Y); y <= chunkOf(near.Max.Y); y++ {
+			c := chunk{x, y}
+			if _, ok := af.chunks[c]; ok || c.rect().Center().Sub(center).Len() > streamRange {
+				continue
+			}
+			af.chunks[c] = af.generate(c)
+			spawned = append(spawned, af.chunks[c]...)
+		}
+	}
+	return spawned, despawned
+}
+
+// The asteroids that are streamed in, a chunk at a time from the bottom left
+func (af *AsteroidField) Asteroids() []*Asteroid {
+	var asteroids []*Asteroid
+	for _, c := range af.streamed() {
+		asteroids = append(asteroids, af.chunks[c]...)
+	}
+	return asteroids
+}
+
+// streamed is the chunks that are streamed in, always in the same order
+func (af *AsteroidField) streamed() []chunk {
+	chunks := make([]chunk, 0, len(af.chunks))
+	for c := range af.chunks {
+		chunks = append(chunks, c)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].x != chunks[j].x {
+			return chunks[i].x < chunks[j].x
+		}
+		return chunks[i].y < chunks[j].y
+	})
+	return chunks
+}
+
+func chunkOf(coordinate float64) int {
+	return int(math.Floor(coordinate / asteroidChunk))
+}
+
+func (c chunk) rect() pixel.Rect {
+	min := pixel.V(float64(c.x), float64(c.y)).Scaled(asteroidChunk)
+	return pixel.R(min.X, min.Y, min.X+asteroidChunk, min.Y+asteroidChunk)
+}
+
+// generate makes up the asteroids of a chunk, every chunk has its own seed so it doesn't matter what order they come in
+func (af *AsteroidField) generate(c chunk) []*Asteroid {
+	area := c.rect().Intersect(af.config.Region)
+	if area.Area() == 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(af.config.Seed ^ int64(c.x)*73856093 ^ int64(c.y)*19349663))
+
+	// the fraction of an asteroid that is left over turns up sometimes
+	count := int(af.config.Density*area.Area()/1e6 + rng.Float64())
+	asteroids := make([]*Asteroid, count)
+	for i := range asteroids {
+		position := pixel.V(area.Min.X+rng.Float64()*area.W(), area.Min.Y+rng.Float64()*area.H())
+		radius := af.config.MinSize + rng.Float64()*(af.config.MaxSize-af.config.MinSize)
+		asteroids[i] = newAsteroid(rng, position, radius)
+	}
+	return asteroids
+}
+
+// Asteroids are lumps of rock, they're drawn as polygons instead of sprites
+type Asteroid struct {
+	position pixel.Vec
+	angle    float64
+	spin     float64     // radians per second
+	radius   float64     // of the circle around the outline
+	outline  []pixel.Vec // around the asteroid's center, before it's turned
+	color    color.Color
+}
+
+func newAsteroid(rng *rand.Rand, position pixel.Vec, radius float64) *Asteroid {
+	// bigger rocks are lumpier
+	points := 5 + int(math.Min(1, radius/40)*(asteroidPoints-5))
+	outline := make([]pixel.Vec, points)
+	for i := range outline {
+		angle := (float64(i) + rng.Float64()*0.5) * 2 * math.Pi / float64(points)
+		outline[i] = pixel.V(radius*(0.7+rng.Float64()*0.3), 0).Rotated(angle)
+	}
+	shade := 80 + rng.Intn(60)
+	return &Asteroid{
+		position: position,
+		angle:    rng.Float64() * 2 * math.Pi,
+		spin:     (rng.Float64() - 0.5) * 60 / radius,
+		radius:   radius,
+		outline:  outline,
+		color:    color.RGBA{uint8(shade), uint8(shade * 9 / 10), uint8(shade * 4 / 5), 255},
+	}
+}
+
+func (a *Asteroid) Name() string {
+	return "Asteroid"
+}
+
+func (a *Asteroid) Angle() float64 {
+	return a.angle
+}
+
+func (a *Asteroid) Bounds() pixel.Rect {
+	return pixel.R(-a.radius, -a.radius, a.radius, a.radius)
+}
+
+func (a *Asteroid) Coordinates() pixel.Vec {
+	return a.position
+}
+
+// Asteroids stay where they are, they only tumble
+func (a *Asteroid) Velocity() pixel.Vec {
+	return pixel.ZV
+}
+
+func (a *Asteroid) Translate(by pixel.Vec) {
+	a.position = a.position.Add(by)
+}
+
+func (a *Asteroid) Step(dt float64) {
+	a.angle = normalizeAngle(a.angle + a.spin*dt)
+}
+
+// Asteroids are too heavy to push around, ships bounce off them like off a planet
+func (a *Asteroid) Shape() Shape {
+	return CircleShape{a.position, a.radius * 0.85}
+}
+
+func (a *Asteroid) CollisionLayer() CollisionLayer {
+	return CollisionAsteroids
+}
+
+// Asteroids don't bump into each other, or into planets
+func (a *Asteroid) CollisionMask() CollisionLayer {
+	return CollisionShips | CollisionProjectiles
+}
+
+func (a *Asteroid) Restitution() float64 {
+	return 0.3
+}
+
+// Draw the outline filled in, with a lighter edge
+func (a *Asteroid) Draw(renderer Renderer, position pixel.Vec, scale float64) {
+	points := make([]pixel.Vec, len(a.outline))
+	for i, point := range a.outline {
+		points[i] = position.Add(point.Rotated(a.angle).Scaled(scale))
+	}
+	renderer.Polygon(points, 0, a.color)
+	r, g, b, _ := a.color.RGBA()
+	renderer.Polygon(points, 1, color.RGBA{uint8(r>>8) + 40, uint8(g>>8) + 40, uint8(b>>8) + 40, 255})
+}
@@ -0,0 +1,208 @@
+package spacegame
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+type AutopilotMode int
+
+const (
+	AutopilotOff       AutopilotMode = iota
+	AutopilotApproach                // fly to a celestial and stop within landing range
+	AutopilotIntercept               // fly to where a ship is going to be, and stay with it
+	AutopilotMatch                   // go as fast as the target, in the same direction
+	AutopilotHold                    // stay where the autopilot was engaged
+)
+
+func (mode AutopilotMode) String() string {
+	switch mode {
+	case AutopilotApproach:
+		return "Approach"
+	case AutopilotIntercept:
+		return "Intercept"
+	case AutopilotMatch:
+		return "Match velocity"
+	case AutopilotHold:
+		return "Hold position"
+	}
+	return "Off"
+}
+
+const (
+	landingMargin   = 48  // pixels above the surface a ship can land from
+	interceptMargin = 40  // pixels between two ships after an intercept
+	speedTolerance  = 4   // pixels per second, close enough to the velocity the autopilot wants
+	thrustAlignment = 0.2 // radians, the autopilot only thrusts when it's pointing about the right way
+	brakingFactor   = 0.5 // of the acceleration that is planned on for slowing down, turning around takes time too
+	settleTime      = 2   // seconds, close to where it's going the autopilot creeps the rest of the way
+)
+
+// The Autopilot flies a ship the way a pilot would, with the same commands.
+// Every update it looks at the ship and its target and works out what to press, and for how long.
+// AI pilots can use one of their own and send its commands to their ship.
+type Autopilot struct {
+	engine *ShipEngine
+	mode   AutopilotMode
+	target Entity
+	hold   pixel.Vec // where to hold position
+}
+
+func NewAutopilot(engine *ShipEngine) *Autopilot {
+	return &Autopilot{engine: engine}
+}
+
+// Engage the autopilot in a mode, the modes but hold need a target
+func (ap *Autopilot) Engage(mode AutopilotMode, target Entity) {
+	if mode != AutopilotHold && target == nil {
+		mode = AutopilotOff
+	}
+	ap.mode, ap.target = mode, target
+	if ap.engine.ship != nil {
+		ap.hold = ap.engine.ship.Coordinates()
+	}
+}
+
+func (ap *Autopilot) Disengage() {
+	ap.Engage(AutopilotOff, nil)
+}
+
+func (ap *Autopilot) Mode() AutopilotMode {
+	return ap.mode
+}
+
+// What the autopilot is doing, for the HUD
+func (ap *Autopilot) String() string {
+	if ap.target == nil {
+		return ap.mode.String()
+	}
+	return fmt.Sprintf("%s %s", ap.mode, ap.target.Name())
+}
+
+// Commands returns what the pilot should do for the next dt seconds
+func (ap *Autopilot) Commands(dt float64) []pilotAction {
+	ship := ap.engine.ship
+	switch ap.mode {
+	case AutopilotApproach:
+		standoff := float64(landingMargin)
+		if celestial, ok := ap.target.(Celestial); ok {
+			standoff += celestial.Radius()
+		}
+		return ap.arrive(ap.target.Coordinates(), ap.target.Velocity(), standoff, dt)
+	case AutopilotIntercept:
+		standoff := (ship.Bounds().Size().Len()+ap.target.Bounds().Size().Len())/2 + interceptMargin
+		return ap.arrive(ap.lead(), ap.target.Velocity(), standoff, dt)
+	case AutopilotMatch:
+		return ap.steer(ap.target.Velocity(), dt)
+	case AutopilotHold:
+		return ap.arrive(ap.hold, pixel.ZV, 0, dt)
+	}
+	return nil
+}
+
+// The autopilot gives up when its target is gone from the scene
+func (ap *Autopilot) lost(info SceneInformation) bool {
+	if ap.target == nil {
+		return false
+	}
+	for _, entity := range info.Entities {
+		if entity == ap.target {
+			return false
+		}
+	}
+	for _, celestial := range info.Celestials {
+		if Entity(celestial) == ap.target {
+			return false
+		}
+	}
+	return true
+}
+
+// lead predicts where the target will be when the ship gets there at full speed
+func (ap *Autopilot) lead() pixel.Vec {
+	ship := ap.engine.ship
+	offset := ap.target.Coordinates().Sub(ship.Coordinates())
+	velocity := ap.target.Velocity()
+	speed := ap.engine.MaxVel
+
+	// |offset + velocity t| = speed t, a quadratic in t
+	a := velocity.Dot(velocity) - speed*speed
+	b := 2 * offset.Dot(velocity)
+	c := offset.Dot(offset)
+	t := offset.Len() / speed // a guess, for targets too fast to catch
+	if discriminant := b*b - 4*a*c; a != 0 && discriminant >= 0 {
+		root := math.Sqrt(discriminant)
+		soonest := math.Inf(1)
+		for _, candidate := range [2]float64{(-b - root) / (2 * a), (-b + root) / (2 * a)} {
+			if candidate > 0 && candidate < soonest {
+				soonest = candidate
+			}
+		}
+		if !math.IsInf(soonest, 1) {
+			t = soonest
+		}
+	}
+	return ap.target.Coordinates().Add(velocity.Scaled(t))
+}
+
+// arrive flies toward point and comes to a stop standoff away from it, moving along with it
+func (ap *Autopilot) arrive(point, velocity pixel.Vec, standoff, dt float64) []pilotAction {
+	ship := ap.engine.ship
+	offset := point.Sub(ship.Coordinates())
+	distance := offset.Len() - standoff
+
+	// as fast as the ship can still stop in the distance that's left, backing off when it's too close
+	speed := math.Sqrt(2 * ap.engine.Acceleration() * brakingFactor * math.Abs(distance))
+	speed = math.Min(speed, math.Abs(distance)/settleTime)
+	speed = math.Copysign(math.Min(ap.engine.MaxVel, speed), distance)
+	desired := velocity
+	if offset != pixel.ZV {
+		desired = desired.Add(offset.Unit().Scaled(speed))
+	}
+	return ap.steer(desired, dt)
+}
+
+// steer turns the ship toward the change of velocity it needs, and thrusts when it's pointing the right way
+func (ap *Autopilot) steer(desired pixel.Vec, dt float64) []pilotAction {
+	ship := ap.engine.ship
+	change := desired.Sub(ship.Velocity())
+	if change.Len() < speedTolerance {
+		return nil
+	}
+
+	// ships point up at angle 0
+	heading := change.Angle() - math.Pi/2
+	commands := ap.turn(heading, dt)
+	if math.Abs(normalizeAngle(heading-ship.Angle())) < thrustAlignment {
+		// only as long as it takes, so the ship doesn't overshoot
+		burn := math.Min(dt, change.Len()/ap.engine.Acceleration())
+		commands = append(commands, pilotAction{actionAccel, burn})
+	}
+	return commands
+}
+
+// turn spins the ship toward heading, no faster than the thrusters can stop it there
+func (ap *Autopilot) turn(heading, dt float64) []pilotAction {
+	ship := ap.engine.ship
+	delta := normalizeAngle(heading - ship.Angle())
+	acceleration := ap.engine.AngularAcceleration()
+	rate := math.Min(ap.engine.TurnSpeed, math.Sqrt(2*acceleration*math.Abs(delta)))
+	rate = math.Copysign(rate, delta)
+
+	change := rate - ship.angularVelocity
+	burn := math.Min(dt, math.Abs(change)/acceleration)
+	if burn <= 0 && rate == 0 {
+		return nil
+	}
+	// a pilot keeps holding the key when the ship is spinning just right, or the thrusters would stop it
+	if change == 0 {
+		change = rate
+	}
+	// turning left spins the ship counterclockwise, right clockwise
+	if change > 0 {
+		return []pilotAction{{actionTurnLeft, burn}}
+	}
+	return []pilotAction{{actionTurnRight, burn}}
+}
@@ -1,38 +1,127 @@
 package spacegame
 
 import (
+	"math"
+
 	"github.com/faiface/pixel"
 )
 
 type Camera interface {
 	Render(Renderer, Entity)
 	Motion() pixel.Vec
+	Update(dt float64)
+	View() CameraView
+	Zoom() float64
+	ZoomBy(factor float64)
+	WorldToScreen(renderer Renderer, coordinates pixel.Vec) pixel.Vec
+	ScreenToWorld(renderer Renderer, position pixel.Vec) pixel.Vec
+}
+
+// What a camera is looking at: the point in the world at the center of the screen, and how far it is zoomed in
+type CameraView struct {
+	Position pixel.Vec
+	Zoom     float64
+}
+
+func (cv CameraView) WorldToScreen(renderer Renderer, coordinates pixel.Vec) pixel.Vec {
+	return coordinates.Sub(cv.Position).Scaled(cv.Zoom).Add(renderer.Center())
+}
+
+func (cv CameraView) ScreenToWorld(renderer Renderer, position pixel.Vec) pixel.Vec {
+	return position.Sub(renderer.Center()).Scaled(1 / cv.Zoom).Add(cv.Position)
 }
 
+// How much one step of the mouse wheel or the zoom keys zooms
+const zoomStep = 1.25
+
+// The ChaseCamera follows its target, trailing slightly behind it when it speeds up
+// and looking ahead in the direction it is moving.
 type ChaseCamera struct {
-	target Entity
+	target   Entity
+	position pixel.Vec // the point in the world that is in the center of the screen
+	velocity pixel.Vec // of the camera itself, per second
+	settled  bool      // false until the camera has been moved to its target
+
+	zoom    float64
+	minZoom float64
+	maxZoom float64
+
+	stiffness    float64 // how quickly the camera catches up, higher is faster
+	lookAhead    float64 // seconds, the camera looks where the target will be by then
+	maxLookAhead float64 // in world units
 }
 
 func NewChaseCamera(target Entity) *ChaseCamera {
 	return &ChaseCamera{
-		target: target,
+		target:       target,
+		position:     target.Coordinates(),
+		zoom:         1,
+		minZoom:      0.25,
+		maxZoom:      4,
+		stiffness:    6,
+		lookAhead:    0.3,
+		maxLookAhead: 150,
 	}
 }
 
 func (c *ChaseCamera) Render(renderer Renderer, entity Entity) {
 	// we have to transfer the entity from game space to screen space
+	renderer.Render(entity, c.WorldToScreen(renderer, entity.Coordinates()), c.zoom)
+}
 
-	// vector from origin to position
-	offset := c.target.Coordinates().To(pixel.ZV)
-
-	position := entity.Coordinates().Add(offset)
+func (c *ChaseCamera) WorldToScreen(renderer Renderer, coordinates pixel.Vec) pixel.Vec {
+	return c.View().WorldToScreen(renderer, coordinates)
+}
 
-	// we want the player in the center
-	position = position.Add(renderer.Center())
+func (c *ChaseCamera) ScreenToWorld(renderer Renderer, position pixel.Vec) pixel.Vec {
+	return c.View().ScreenToWorld(renderer, position)
+}
 
-	renderer.Render(entity, position)
+func (c *ChaseCamera) View() CameraView {
+	return CameraView{c.position, c.zoom}
 }
 
 func (c *ChaseCamera) Motion() pixel.Vec {
 	return c.target.Velocity()
 }
+
+func (c *ChaseCamera) Zoom() float64 {
+	return c.zoom
+}
+
+// ZoomBy multiplies the zoom by factor, within the camera's limits
+func (c *ChaseCamera) ZoomBy(factor float64) {
+	c.zoom = math.Max(c.minZoom, math.Min(c.maxZoom, c.zoom*factor))
+}
+
+// The point the camera is trying to center on
+func (c *ChaseCamera) focus() pixel.Vec {
+	ahead := c.target.Velocity().Scaled(c.lookAhead)
+	if ahead.Len() > c.maxLookAhead {
+		ahead = ahead.Unit().Scaled(c.maxLookAhead)
+	}
+	return c.target.Coordinates().Add(ahead)
+}
+
+// Snap moves the camera onto its focus right away
+func (c *ChaseCamera) Snap() {
+	c.position, c.velocity, c.settled = c.focus(), pixel.ZV, true
+}
+
+// Update moves the camera toward its focus with a critically damped spring,
+// so it never overshoots. The first update snaps the camera onto the focus.
+func (c *ChaseCamera) Update(dt float64) {
+	if !c.settled {
+		c.Snap()
+		return
+	}
+	focus := c.focus()
+
+	// exact enough for any dt, see "Critically Damped Ease-In/Ease-Out Smoothing" in Game Programming Gems 4
+	x := c.stiffness * dt
+	decay := 1 / (1 + x + 0.48*x*x + 0.235*x*x*x)
+	change := c.position.Sub(focus)
+	temp := c.velocity.Add(change.Scaled(c.stiffness)).Scaled(dt)
+	c.velocity = c.velocity.Sub(temp.Scaled(c.stiffness)).Scaled(decay)
+	c.position = focus.Add(change.Add(temp).Scaled(decay))
+}
@@ -0,0 +1,220 @@
+package spacegame
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+// A CameraEffect changes what a camera is looking at.
+// Apply is called once per update with the view so far, it returns false when the effect is over.
+type CameraEffect interface {
+	Apply(view CameraView, dt float64) (CameraView, bool)
+}
+
+// The EffectCamera puts a stack of effects on top of another camera.
+// Effects are applied in the order they were pushed, screen shake always goes last.
+// Everything random comes from the seed, so the same updates always give the same views.
+type EffectCamera struct {
+	base     Camera
+	effects  []CameraEffect
+	shake    *ScreenShake
+	freeLook *FreeLook
+	view     CameraView
+}
+
+func NewEffectCamera(base Camera, seed int64) *EffectCamera {
+	return &EffectCamera{
+		base:  base,
+		shake: NewScreenShake(rand.New(rand.NewSource(seed))),
+		view:  base.View(),
+	}
+}
+
+// Push an effect on top of the stack
+func (ec *EffectCamera) Push(effect CameraEffect) {
+	ec.effects = append(ec.effects, effect)
+}
+
+// Shake the camera, trauma adds up to a maximum of 1
+func (ec *EffectCamera) Shake(trauma float64) {
+	ec.shake.AddTrauma(trauma)
+}
+
+// Detach the camera to look around freely, or return to the base camera
+func (ec *EffectCamera) ToggleFreeLook() {
+	if ec.freeLook != nil {
+		ec.freeLook.done = true
+		ec.freeLook = nil
+		return
+	}
+	ec.freeLook = &FreeLook{position: ec.view.Position, speed: 600}
+	ec.Push(ec.freeLook)
+}
+
+// Move the free-look camera, if it is detached
+func (ec *EffectCamera) Pan(direction pixel.Vec, dt float64) {
+	if ec.freeLook != nil {
+		ec.freeLook.Pan(direction, dt, ec.view.Zoom)
+	}
+}
+
+func (ec *EffectCamera) Update(dt float64) {
+	ec.base.Update(dt)
+
+	view := ec.base.View()
+	remaining := ec.effects[:0]
+	for _, effect := range ec.effects {
+		var alive bool
+		if view, alive = effect.Apply(view, dt); alive {
+			remaining = append(remaining, effect)
+		}
+	}
+	ec.effects = remaining
+	ec.view, _ = ec.shake.Apply(view, dt)
+}
+
+func (ec *EffectCamera) Render(renderer Renderer, entity Entity) {
+	renderer.Render(entity, ec.WorldToScreen(renderer, entity.Coordinates()), ec.view.Zoom)
+}
+
+// The stars are too far away to notice effects, they only follow the base camera
+func (ec *EffectCamera) Motion() pixel.Vec {
+	return ec.base.Motion()
+}
+
+func (ec *EffectCamera) View() CameraView {
+	return ec.view
+}
+
+func (ec *EffectCamera) Zoom() float64 {
+	return ec.view.Zoom
+}
+
+func (ec *EffectCamera) ZoomBy(factor float64) {
+	ec.base.ZoomBy(factor)
+}
+
+func (ec *EffectCamera) WorldToScreen(renderer Renderer, coordinates pixel.Vec) pixel.Vec {
+	return ec.view.WorldToScreen(renderer, coordinates)
+}
+
+func (ec *EffectCamera) ScreenToWorld(renderer Renderer, position pixel.Vec) pixel.Vec {
+	return ec.view.ScreenToWorld(renderer, position)
+}
+
+// ScreenShake shakes the camera by the square of its trauma, which wears off over time.
+// Impacts and explosions add trauma.
+type ScreenShake struct {
+	rng       *rand.Rand
+	trauma    float64
+	recovery  float64 // trauma lost per second
+	maxOffset float64 // in pixels, at full trauma
+}
+
+func NewScreenShake(rng *rand.Rand) *ScreenShake {
+	return &ScreenShake{
+		rng:       rng,
+		recovery:  1,
+		maxOffset: 24,
+	}
+}
+
+func (ss *ScreenShake) AddTrauma(trauma float64) {
+	ss.trauma = math.Min(ss.trauma+trauma, 1)
+}
+
+// A screen shake never ends, it just stops shaking
+func (ss *ScreenShake) Apply(view CameraView, dt float64) (CameraView, bool) {
+	if ss.trauma <= 0 {
+		return view, true
+	}
+	shake := ss.trauma * ss.trauma * ss.maxOffset
+	offset := pixel.V(ss.rng.Float64()*2-1, ss.rng.Float64()*2-1).Scaled(shake / view.Zoom)
+	view.Position = view.Position.Add(offset)
+
+	ss.trauma = math.Max(ss.trauma-ss.recovery*dt, 0)
+	return view, true
+}
+
+// A Cinematic moves the camera along a smooth path through its waypoints, easing in and out.
+// The zoom is interpolated between the waypoints as well.
+type Cinematic struct {
+	waypoints []CameraView
+	duration  float64 // seconds
+	elapsed   float64
+}
+
+func NewCinematic(duration float64, waypoints ...CameraView) *Cinematic {
+	return &Cinematic{
+		waypoints: waypoints,
+		duration:  duration,
+	}
+}
+
+func (c *Cinematic) Apply(view CameraView, dt float64) (CameraView, bool) {
+	if len(c.waypoints) == 0 {
+		return view, false
+	}
+	c.elapsed += dt
+	if c.elapsed >= c.duration {
+		return view, false
+	}
+	return c.At(c.elapsed / c.duration), true
+}
+
+// At returns the view at t, from 0 (the first waypoint) to 1 (the last)
+func (c *Cinematic) At(t float64) CameraView {
+	t = math.Max(0, math.Min(1, t))
+	t = t * t * (3 - 2*t) // ease in and out
+
+	last := len(c.waypoints) - 1
+	if last == 0 {
+		return c.waypoints[0]
+	}
+	segment := math.Min(math.Floor(t*float64(last)), float64(last-1))
+	local := t*float64(last) - segment
+
+	// Catmull-Rom through the waypoints, the ends are repeated
+	point := func(i int) CameraView {
+		return c.waypoints[int(math.Max(0, math.Min(float64(last), float64(i))))]
+	}
+	i := int(segment)
+	p0, p1, p2, p3 := point(i-1), point(i), point(i+1), point(i+2)
+
+	return CameraView{
+		Position: pixel.V(
+			catmullRom(p0.Position.X, p1.Position.X, p2.Position.X, p3.Position.X, local),
+			catmullRom(p0.Position.Y, p1.Position.Y, p2.Position.Y, p3.Position.Y, local),
+		),
+		Zoom: p1.Zoom + (p2.Zoom-p1.Zoom)*local,
+	}
+}
+
+func catmullRom(p0, p1, p2, p3, t float64) float64 {
+	return 0.5 * (2*p1 +
+		(p2-p0)*t +
+		(2*p0-5*p1+4*p2-p3)*t*t +
+		(3*p1-p0-3*p2+p3)*t*t*t)
+}
+
+// FreeLook detaches the camera from whatever it was following, it keeps the zoom
+type FreeLook struct {
+	position pixel.Vec
+	speed    float64 // pixels per second on the screen
+	done     bool
+}
+
+// Pan moves in direction, faster when zoomed out so that it looks the same on the screen
+func (fl *FreeLook) Pan(direction pixel.Vec, dt, zoom float64) {
+	fl.position = fl.position.Add(direction.Scaled(fl.speed * dt / zoom))
+}
+
+func (fl *FreeLook) Apply(view CameraView, dt float64) (CameraView, bool) {
+	if fl.done {
+		return view, false
+	}
+	view.Position = fl.position
+	return view, true
+}
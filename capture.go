@@ -0,0 +1,125 @@
+package spacegame
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const timestampFormat = "20060102-150405.000"
+
+// FrameRecorder saves rendered frames as PNG files.
+// Screenshots save the next frame, while capture mode saves every Nth frame to its own directory, for making clips.
+// Requests can come from any goroutine, but frames are only captured in Frame, after the scene has been rendered.
+type FrameRecorder struct {
+	directory string
+	every     int // capture every Nth frame
+
+	mutex      sync.Mutex
+	screenshot bool   // a screenshot was requested
+	captureDir string // empty unless capturing
+	frame      int    // frames since the capture started
+	pending    sync.WaitGroup
+	now        func() time.Time
+}
+
+func NewFrameRecorder(directory string, every int) *FrameRecorder {
+	if every < 1 {
+		every = 1
+	}
+	return &FrameRecorder{
+		directory: directory,
+		every:     every,
+		now:       time.Now,
+	}
+}
+
+// Save the next frame as a timestamped PNG
+func (fr *FrameRecorder) Screenshot() {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+	fr.screenshot = true
+}
+
+// Start capturing frames into a new directory, or stop capturing
+func (fr *FrameRecorder) ToggleCapture() {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+	if fr.captureDir != "" {
+		log.Println("Stopped capturing to", fr.captureDir)
+		fr.captureDir = ""
+		return
+	}
+	fr.captureDir = filepath.Join(fr.directory, "capture-"+fr.now().Format(timestampFormat))
+	fr.frame = 0
+	log.Println("Capturing every", fr.every, "frames to", fr.captureDir)
+}
+
+func (fr *FrameRecorder) Capturing() bool {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+	return fr.captureDir != ""
+}
+
+// Frame should be called once every frame, after the frame was rendered
+func (fr *FrameRecorder) Frame(renderer Renderer) {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+
+	var paths []string
+	if fr.screenshot {
+		fr.screenshot = false
+		paths = append(paths, filepath.Join(fr.directory, "screenshot-"+fr.now().Format(timestampFormat)+".png"))
+	}
+	if fr.captureDir != "" {
+		if fr.frame%fr.every == 0 {
+			paths = append(paths, filepath.Join(fr.captureDir, fmt.Sprintf("frame-%06d.png", fr.frame/fr.every)))
+		}
+		fr.frame++
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	img, err := renderer.Capture()
+	if err != nil {
+		log.Println("Could not capture frame:", err)
+		return
+	}
+
+	// encoding is slow, don't hold up the game
+	for _, path := range paths {
+		fr.pending.Add(1)
+		go func(path string) {
+			defer fr.pending.Done()
+			if err := savePNG(path, img); err != nil {
+				log.Println("Could not save frame:", err)
+			}
+		}(path)
+	}
+}
+
+// Wait until all captured frames have been written
+func (fr *FrameRecorder) Wait() {
+	fr.pending.Wait()
+}
+
+func savePNG(path string, img image.Image) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return png.Encode(file, img)
+}
@@ -15,61 +15,119 @@ type BaseCelestial struct {
 	name      string
 	imagePath string
 	bounds    pixel.Rect
+	origin    pixel.Vec // where the celestial was put, orbits without a parent go round it
 	position  pixel.Vec
+	velocity  pixel.Vec
 	radius    float64
+	mass      float64 // tonnes, celestials without mass have no pull
+	influence float64 // how far the pull reaches
+	orbit     *OrbitConfig
+	parent    Celestial // what the orbit goes round, if anything
+}
+
+// The solar system reaches into celestials to move them along their orbits
+type celestialBase interface {
+	base() *BaseCelestial
 }
 
 func NewCelestial(name, imagePath string, position pixel.Vec) Celestial {
-	return DockableCelestial{ // TODO: Return uninhabitable celestials by default, and wrap this
+	return &DockableCelestial{ // TODO: Return uninhabitable celestials by default, and wrap this
 		BaseCelestial{
 			name:      name,
 			imagePath: imagePath,
+			origin:    position,
 			position:  position,
 			radius:    64.0, // TODO: Get from imagePath? makes docking adaptive
+			influence: 64.0 * defaultInfluence,
 		},
 	}
 }
 
-func (c BaseCelestial) Name() string {
+func (c *BaseCelestial) Name() string {
 	return c.name
 }
 
-func (c BaseCelestial) ImagePath() string {
+func (c *BaseCelestial) ImagePath() string {
 	return c.imagePath
 }
 
-func (c BaseCelestial) Angle() float64 {
+func (c *BaseCelestial) Angle() float64 {
 	return 0.0
 }
 
-func (c BaseCelestial) Bounds() pixel.Rect {
+func (c *BaseCelestial) Bounds() pixel.Rect {
 	// center the celestial around its origin
 	return pixel.R(-c.radius, -c.radius, c.radius, c.radius)
 }
 
-func (c BaseCelestial) Coordinates() pixel.Vec {
+func (c *BaseCelestial) Coordinates() pixel.Vec {
 	return c.position
 }
 
-func (c BaseCelestial) Radius() float64 {
+func (c *BaseCelestial) Radius() float64 {
 	return c.radius
 }
 
-func (c BaseCelestial) Velocity() pixel.Vec {
-	return pixel.ZV
+func (c *BaseCelestial) Mass() float64 {
+	return c.mass
+}
+
+func (c *BaseCelestial) Influence() float64 {
+	return c.influence
 }
 
-func (c BaseCelestial) Translate(by pixel.Vec) {
+func (c *BaseCelestial) Velocity() pixel.Vec {
+	return c.velocity
+}
+
+// Translate moves the celestial, and the middle of its orbit if it has no parent
+func (c *BaseCelestial) Translate(by pixel.Vec) {
+	c.origin = c.origin.Add(by)
 	c.position = c.position.Add(by)
 }
 
+func (c *BaseCelestial) base() *BaseCelestial {
+	return c
+}
+
+// moveTo puts the celestial where its orbit takes it by time t, its parent has to be there already
+func (c *BaseCelestial) moveTo(t float64) {
+	if c.orbit == nil {
+		return
+	}
+	center, drift := c.origin, pixel.ZV
+	if c.parent != nil {
+		center, drift = c.parent.Coordinates(), c.parent.Velocity()
+	}
+	offset, velocity := c.orbit.At(t)
+	c.position, c.velocity = center.Add(offset), drift.Add(velocity)
+}
+
+func (c *BaseCelestial) Shape() Shape {
+	return CircleShape{c.position, c.radius}
+}
+
+func (c *BaseCelestial) CollisionLayer() CollisionLayer {
+	return CollisionCelestials
+}
+
+// Ships hardly bounce off a planet, they crash into it
+func (c *BaseCelestial) Restitution() float64 {
+	return 0.2
+}
+
+// Celestials don't run into each other
+func (c *BaseCelestial) CollisionMask() CollisionLayer {
+	return CollisionAll &^ CollisionCelestials
+}
+
 // Dockables can be inhabited, or not.
 // If it is not inhabited, it can either be terraformed or colonized, based on whether or not it is hospitable or not.
 type DockableCelestial struct {
 	BaseCelestial
 }
 
-func (dc DockableCelestial) Land(ship *Ship) bool {
+func (dc *DockableCelestial) Land(ship *Ship) bool {
 	// If the player is in range
 	// TODO: Notify the game engine that the ship has landed so that a LandedScene can be rendered.
 
@@ -88,9 +146,35 @@ type CelestialConfig struct {
 	Coordinates pixel.Vec
 	ImagePath   string
 	Radius      float64
+	Mass        float64      `json:",omitempty"` // tonnes, for gravity
+	Influence   float64      `json:",omitempty"` // how far the gravity reaches, a number of radii by default
+	Orbit       *OrbitConfig `json:",omitempty"`
 	Colony      Colony
 }
 
+func (config CelestialConfig) load() Celestial {
+	radius := config.Radius
+	if radius <= 0 {
+		radius = 64.0
+	}
+	influence := config.Influence
+	if influence <= 0 {
+		influence = radius * defaultInfluence
+	}
+	return &DockableCelestial{
+		BaseCelestial{
+			name:      config.Name,
+			imagePath: config.ImagePath,
+			origin:    config.Coordinates,
+			position:  config.Coordinates,
+			radius:    radius,
+			mass:      config.Mass,
+			influence: influence,
+			orbit:     config.Orbit,
+		},
+	}
+}
+
 func (cs CelestialCollection) Config() []CelestialConfig {
 	var collection []CelestialConfig
 
@@ -101,6 +185,15 @@ func (cs CelestialCollection) Config() []CelestialConfig {
 			Coordinates: c.Coordinates(),
 			Radius:      c.Radius(),
 		}
+		// orbiting celestials are saved where they were put, the time says where they went
+		if b, ok := c.(celestialBase); ok {
+			config.Coordinates = b.base().origin
+			config.Orbit = b.base().orbit
+		}
+		if well, ok := c.(GravityWell); ok && well.Mass() > 0 {
+			config.Mass = well.Mass()
+			config.Influence = well.Influence()
+		}
 		collection = append(collection, config)
 	}
 
@@ -0,0 +1,377 @@
+package spacegame
+
+import (
+	"math"
+	"sort"
+
+	"github.com/faiface/pixel"
+)
+
+// CollisionLayers say what a collider is, and what it collides with
+type CollisionLayer uint32
+
+const (
+	CollisionShips CollisionLayer = 1 << iota
+	CollisionProjectiles
+	CollisionAsteroids
+	CollisionCelestials
+	CollisionMissiles
+
+	CollisionAll CollisionLayer = math.MaxUint32
+)
+
+// A Collider is an entity that can bump into things.
+// Two colliders collide when each one's layer is in the other's mask.
+type Collider interface {
+	Entity
+	Shape() Shape
+	CollisionLayer() CollisionLayer
+	CollisionMask() CollisionLayer
+}
+
+// A Shape is the outline of a collider, in world coordinates
+type Shape interface {
+	// The axis aligned box around the shape
+	Bounds() pixel.Rect
+}
+
+type CircleShape struct {
+	Center pixel.Vec
+	Radius float64
+}
+
+func (c CircleShape) Bounds() pixel.Rect {
+	return pixel.R(c.Center.X-c.Radius, c.Center.Y-c.Radius, c.Center.X+c.Radius, c.Center.Y+c.Radius)
+}
+
+// A BoxShape is a rectangle turned by Angle around its center
+type BoxShape struct {
+	Center   pixel.Vec
+	HalfSize pixel.Vec
+	Angle    float64
+}
+
+// The shape of an entity's bounds, turned with it
+func entityBox(entity Entity) BoxShape {
+	return BoxShape{entity.Coordinates(), entity.Bounds().Size().Scaled(0.5), entity.Angle()}
+}
+
+func (b BoxShape) Bounds() pixel.Rect {
+	// the corners of the box are only this far from its center along each axis
+	cos, sin := math.Abs(math.Cos(b.Angle)), math.Abs(math.Sin(b.Angle))
+	extent := pixel.V(b.HalfSize.X*cos+b.HalfSize.Y*sin, b.HalfSize.X*sin+b.HalfSize.Y*cos)
+	return pixel.Rect{Min: b.Center.Sub(extent), Max: b.Center.Add(extent)}
+}
+
+// The directions of the box's sides
+func (b BoxShape) axes() [2]pixel.Vec {
+	x := pixel.V(1, 0).Rotated(b.Angle)
+	return [2]pixel.Vec{x, x.Normal()}
+}
+
+// How far the box reaches from its center along axis
+func (b BoxShape) reach(axis pixel.Vec) float64 {
+	axes := b.axes()
+	return b.HalfSize.X*math.Abs(axes[0].Dot(axis)) + b.HalfSize.Y*math.Abs(axes[1].Dot(axis))
+}
+
+// A Contact is two colliders touching
+type Contact struct {
+	A, B        Collider
+	Normal      pixel.Vec // the direction B has to move to get out of A
+	Penetration float64   // and how far
+	Point       pixel.Vec // where they touch, about
+	i, j        int       // the order of A and B in the colliders
+}
+
+// collide checks two shapes against each other, the contact is only filled in with where they touch
+func collide(a, b Shape) (Contact, bool) {
+	switch a := a.(type) {
+	case CircleShape:
+		switch b := b.(type) {
+		case CircleShape:
+			return collideCircles(a, b)
+		case BoxShape:
+			contact, ok := collideBoxCircle(b, a)
+			contact.Normal = contact.Normal.Scaled(-1)
+			return contact, ok
+		}
+	case BoxShape:
+		switch b := b.(type) {
+		case CircleShape:
+			return collideBoxCircle(a, b)
+		case BoxShape:
+			return collideBoxes(a, b)
+		}
+	}
+	return Contact{}, false
+}
+
+func collideCircles(a, b CircleShape) (Contact, bool) {
+	offset := b.Center.Sub(a.Center)
+	distance := offset.Len()
+	penetration := a.Radius + b.Radius - distance
+	if penetration <= 0 {
+		return Contact{}, false
+	}
+	normal := pixel.V(0, 1) // right on top of each other, any way out will do
+	if distance > 0 {
+		normal = offset.Scaled(1 / distance)
+	}
+	point := a.Center.Add(normal.Scaled(a.Radius - penetration/2))
+	return Contact{Normal: normal, Penetration: penetration, Point: point}, true
+}
+
+func collideBoxCircle(box BoxShape, circle CircleShape) (Contact, bool) {
+	// work in the box's frame, where it isn't turned
+	local := circle.Center.Sub(box.Center).Rotated(-box.Angle)
+	closest := pixel.V(
+		math.Max(-box.HalfSize.X, math.Min(box.HalfSize.X, local.X)),
+		math.Max(-box.HalfSize.Y, math.Min(box.HalfSize.Y, local.Y)),
+	)
+	point := box.Center.Add(closest.Rotated(box.Angle))
+
+	if closest != local {
+		offset := local.Sub(closest)
+		distance := offset.Len()
+		if distance >= circle.Radius {
+			return Contact{}, false
+		}
+		return Contact{Normal: offset.Scaled(1 / distance).Rotated(box.Angle), Penetration: circle.Radius - distance, Point: point}, true
+	}
+
+	// the center is inside the box, push it out through the nearest side
+	dx, dy := box.HalfSize.X-math.Abs(local.X), box.HalfSize.Y-math.Abs(local.Y)
+	normal, depth := pixel.V(math.Copysign(1, local.X), 0), dx
+	if dy < dx {
+		normal, depth = pixel.V(0, math.Copysign(1, local.Y)), dy
+	}
+	return Contact{Normal: normal.Rotated(box.Angle), Penetration: circle.Radius + depth, Point: point}, true
+}
+
+// Boxes are tested with separating axes, they overlap unless there's a gap along one of their sides
+func collideBoxes(a, b BoxShape) (Contact, bool) {
+	offset := b.Center.Sub(a.Center)
+	axesA, axesB := a.axes(), b.axes()
+
+	normal, penetration, side := pixel.ZV, math.Inf(1), 0
+	for i, axis := range [4]pixel.Vec{axesA[0], axesA[1], axesB[0], axesB[1]} {
+		distance := offset.Dot(axis)
+		overlap := a.reach(axis) + b.reach(axis) - math.Abs(distance)
+		if overlap <= 0 {
+			return Contact{}, false
+		}
+		if overlap < penetration {
+			normal, penetration, side = axis, overlap, i
+			if distance < 0 {
+				normal = axis.Scaled(-1)
+			}
+		}
+	}
+
+	// a corner of one box is in a side of the other, the deepest one is about where they touch.
+	// Boxes side by side have no deepest corner, that's what the clamping is for.
+	var point pixel.Vec
+	if side < 2 {
+		point = a.clamp(b.corner(normal.Scaled(-1)).Add(normal.Scaled(penetration / 2)))
+	} else {
+		point = b.clamp(a.corner(normal).Sub(normal.Scaled(penetration / 2)))
+	}
+	return Contact{Normal: normal, Penetration: penetration, Point: point}, true
+}
+
+// The point in the box closest to p
+func (b BoxShape) clamp(p pixel.Vec) pixel.Vec {
+	local := p.Sub(b.Center).Rotated(-b.Angle)
+	local = pixel.V(
+		math.Max(-b.HalfSize.X, math.Min(b.HalfSize.X, local.X)),
+		math.Max(-b.HalfSize.Y, math.Min(b.HalfSize.Y, local.Y)),
+	)
+	return b.Center.Add(local.Rotated(b.Angle))
+}
+
+// The corner of the box farthest along direction
+func (b BoxShape) corner(direction pixel.Vec) pixel.Vec {
+	axes := b.axes()
+	corner := b.Center
+	for i, half := range [2]float64{b.HalfSize.X, b.HalfSize.Y} {
+		corner = corner.Add(axes[i].Scaled(math.Copysign(half, axes[i].Dot(direction))))
+	}
+	return corner
+}
+
+// The CollisionSystem finds the colliders that touch.
+// Colliders are put into the cells of a spatial hash first, only colliders that share a cell are tested against each other.
+// Everything is kept between calls, so detecting doesn't allocate once it has warmed up.
+type CollisionSystem struct {
+	cellSize  float64
+	cells     map[cell][]int // indices into colliders
+	colliders []Collider
+	shapes    []Shape
+	bounds    []pixel.Rect
+	layers    []CollisionLayer
+	masks     []CollisionLayer
+	contacts  []Contact
+}
+
+type cell struct {
+	x, y int
+}
+
+// The cell size should be around the size of a ship, big things take up more cells
+func NewCollisionSystem(cellSize float64) *CollisionSystem {
+	return &CollisionSystem{
+		cellSize: cellSize,
+		cells:    make(map[cell][]int),
+	}
+}
+
+func (cs *CollisionSystem) cellAt(v pixel.Vec) cell {
+	return cell{int(math.Floor(v.X / cs.cellSize)), int(math.Floor(v.Y / cs.cellSize))}
+}
+
+// Detect returns a contact for every pair of colliders that touch.
+// The contacts are only good until the next call.
+func (cs *CollisionSystem) Detect(colliders []Collider) []Contact {
+	cs.colliders = colliders
+	cs.shapes = cs.shapes[:0]
+	cs.bounds = cs.bounds[:0]
+	cs.layers = cs.layers[:0]
+	cs.masks = cs.masks[:0]
+	cs.contacts = cs.contacts[:0]
+
+	for key, indices := range cs.cells {
+		// forget the cells nobody was in last time
+		if len(indices) == 0 {
+			delete(cs.cells, key)
+			continue
+		}
+		cs.cells[key] = indices[:0]
+	}
+
+	for i, collider := range colliders {
+		shape := collider.Shape()
+		bounds := shape.Bounds()
+		cs.shapes = append(cs.shapes, shape)
+		cs.bounds = append(cs.bounds, bounds)
+		cs.layers = append(cs.layers, collider.CollisionLayer())
+		cs.masks = append(cs.masks, collider.CollisionMask())
+
+		min, max := cs.cellAt(bounds.Min), cs.cellAt(bounds.Max)
+		for x := min.x; x <= max.x; x++ {
+			for y := min.y; y <= max.y; y++ {
+				key := cell{x, y}
+				cs.cells[key] = append(cs.cells[key], i)
+			}
+		}
+	}
+
+	// every cell lists its colliders in order, so i < j
+	for key, indices := range cs.cells {
+		for n, i := range indices {
+			for _, j := range indices[n+1:] {
+				cs.test(key, i, j)
+			}
+		}
+	}
+	// the cells come out of the map in any order, the contacts shouldn't
+	sort.Slice(cs.contacts, func(m, n int) bool {
+		a, b := cs.contacts[m], cs.contacts[n]
+		return a.i < b.i || a.i == b.i && a.j < b.j
+	})
+	return cs.contacts
+}
+
+func (cs *CollisionSystem) test(key cell, i, j int) {
+	if cs.layers[i]&cs.masks[j] == 0 || cs.layers[j]&cs.masks[i] == 0 {
+		return
+	}
+
+	boundsA, boundsB := cs.bounds[i], cs.bounds[j]
+	if boundsA.Max.X < boundsB.Min.X || boundsB.Max.X < boundsA.Min.X ||
+		boundsA.Max.Y < boundsB.Min.Y || boundsB.Max.Y < boundsA.Min.Y {
+		return
+	}
+	// pairs that share several cells are only tested in the cell where their bounds start to overlap
+	overlap := pixel.V(math.Max(boundsA.Min.X, boundsB.Min.X), math.Max(boundsA.Min.Y, boundsB.Min.Y))
+	if cs.cellAt(overlap) != key {
+		return
+	}
+
+	contact, ok := collide(cs.shapes[i], cs.shapes[j])
+	if ok {
+		contact.A, contact.B, contact.i, contact.j = cs.colliders[i], cs.colliders[j], i, j
+		cs.contacts = append(cs.contacts, contact)
+	}
+}
+
+// Colliders that move get pushed around by collisions, anything else is immovable
+type Movable interface {
+	Mass() float64
+	ApplyImpulse(impulse pixel.Vec)
+}
+
+// Colliders that can be hurt take damage from impacts
+type Damageable interface {
+	Damage(amount float64)
+}
+
+// Entities that can be taken apart say when they are, and the scene removes them
+type Destructible interface {
+	Destroyed() bool
+}
+
+// Colliders that aren't as bouncy as a ship say how much of their speed they keep
+type Bouncy interface {
+	Restitution() float64
+}
+
+const (
+	defaultRestitution = 0.5
+	impactDamage       = 1.0 / 100000 // per unit of kinetic energy, the Starbridge hitting a planet at full speed loses about half its hull
+	minImpactDamage    = 1            // bumps that do less than this are shrugged off
+)
+
+func inverseMass(collider Collider) float64 {
+	if movable, ok := collider.(Movable); ok && movable.Mass() > 0 {
+		return 1 / movable.Mass()
+	}
+	return 0
+}
+
+func restitution(collider Collider) float64 {
+	if bouncy, ok := collider.(Bouncy); ok {
+		return bouncy.Restitution()
+	}
+	return defaultRestitution
+}
+
+// resolve pushes the colliders of a contact apart and bounces them off each other.
+// It returns the kinetic energy the bounce didn't give back, which is what hurts.
+func resolve(contact Contact) float64 {
+	inverseA, inverseB := inverseMass(contact.A), inverseMass(contact.B)
+	total := inverseA + inverseB
+	if total == 0 {
+		return 0
+	}
+
+	// the lighter one moves more
+	contact.A.Translate(contact.Normal.Scaled(-contact.Penetration * inverseA / total))
+	contact.B.Translate(contact.Normal.Scaled(contact.Penetration * inverseB / total))
+
+	closing := contact.B.Velocity().Sub(contact.A.Velocity()).Dot(contact.Normal)
+	if closing >= 0 {
+		return 0 // they are moving apart already
+	}
+	e := math.Min(restitution(contact.A), restitution(contact.B))
+	impulse := contact.Normal.Scaled(-(1 + e) * closing / total)
+	if movable, ok := contact.A.(Movable); ok && inverseA > 0 {
+		movable.ApplyImpulse(impulse.Scaled(-1))
+	}
+	if movable, ok := contact.B.(Movable); ok && inverseB > 0 {
+		movable.ApplyImpulse(impulse)
+	}
+
+	// the energy of the impact is ½μv², with the reduced mass μ, and the bounce keeps e² of it
+	return 0.5 / total * closing * closing * (1 - e*e)
+}
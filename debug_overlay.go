@@ -0,0 +1,238 @@
+package spacegame
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"runtime"
+
+	"golang.org/x/image/colornames"
+
+	"github.com/faiface/pixel"
+)
+
+var (
+	debugBounds    = colornames.Lime
+	debugVelocity  = colornames.Cyan
+	debugHeading   = colornames.Yellow
+	debugScanner   = color.RGBA{0, 96, 0, 96}
+	debugLanding   = colornames.Magenta
+	debugTarget    = colornames.Orange
+	debugContact   = colornames.Red
+	debugGravity   = color.RGBA{48, 80, 160, 160}
+	debugGraph     = colornames.Lime
+	debugGraphSlow = colornames.Red
+)
+
+// How many frames the frame-time graph shows
+const debugFrames = 120
+
+// The DebugOverlay draws what the game is thinking on top of the scene:
+// gravity, bounds, velocities, headings, scanner ranges, landing radii, target lines and contacts,
+// and a panel with the frame rate, frame times and how many things there are.
+type DebugOverlay struct {
+	enabled    bool
+	frameTimes [debugFrames]float64 // seconds, a ring buffer
+	frame      int                  // the next frame in the ring buffer
+	counts     debugCounts
+	goroutines func() int
+}
+
+type debugCounts struct {
+	entities, celestials, particles int
+}
+
+func NewDebugOverlay() *DebugOverlay {
+	return &DebugOverlay{
+		goroutines: runtime.NumGoroutine,
+	}
+}
+
+func (do *DebugOverlay) Toggle() {
+	do.enabled = !do.enabled
+}
+
+func (do *DebugOverlay) Enabled() bool {
+	return do.enabled
+}
+
+// Frame records how long a frame took
+func (do *DebugOverlay) Frame(dt float64) {
+	do.frameTimes[do.frame] = dt
+	do.frame = (do.frame + 1) % debugFrames
+}
+
+// The average frame rate over the recorded frames
+func (do *DebugOverlay) FPS() float64 {
+	total, frames := 0.0, 0
+	for _, dt := range do.frameTimes {
+		if dt > 0 {
+			total += dt
+			frames++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(frames) / total
+}
+
+// Render draws the overlay for everything in the scene, in screen space
+func (do *DebugOverlay) Render(renderer Renderer, camera Camera, info SceneInformation) {
+	if !do.enabled {
+		return
+	}
+	do.counts = debugCounts{len(info.Entities), len(info.Celestials), 0}
+	if info.Particles != nil {
+		do.counts.particles = info.Particles.Count()
+	}
+
+	zoom := camera.Zoom()
+	do.gravity(renderer, camera, info.Celestials)
+	for _, celestial := range info.Celestials {
+		position := camera.WorldToScreen(renderer, celestial.Coordinates())
+		renderer.Circle(position, celestial.Radius()*zoom, 1, debugLanding)
+	}
+
+	for _, entity := range info.Entities {
+		position := camera.WorldToScreen(renderer, entity.Coordinates())
+		do.bounds(renderer, entity, position, zoom)
+
+		// where it will be in half a second
+		renderer.Line(position, position.Add(entity.Velocity().Scaled(0.5*zoom)), 1, debugVelocity)
+
+		// ships point up at angle 0
+		heading := pixel.V(0, entity.Bounds().H()*zoom).Rotated(entity.Angle())
+		renderer.Line(position, position.Add(heading), 1, debugHeading)
+
+		ship, ok := entity.(*Ship)
+		if !ok {
+			continue
+		}
+		scanner, ok := ship.systems["scanner"].(*ShipScanner)
+		if !ok {
+			continue
+		}
+		do.rangeCircle(renderer, position, scanner.Range*zoom, debugScanner)
+		for _, target := range []Entity{scanner.Target(), scanner.Celestial()} {
+			if target != nil {
+				renderer.Line(position, camera.WorldToScreen(renderer, target.Coordinates()), 1, debugTarget)
+			}
+		}
+	}
+
+	// where things touched in the last step, and the way the second one was pushed out
+	for _, contact := range info.Contacts {
+		point := camera.WorldToScreen(renderer, contact.Point)
+		renderer.Circle(point, 3, 1, debugContact)
+		length := math.Max(8, contact.Penetration*zoom)
+		renderer.Line(point, point.Add(contact.Normal.Scaled(length)), 1, debugContact)
+	}
+}
+
+// Gravity is drawn as a field of arrows pointing where things fall, longer where the pull is stronger,
+// and a circle where each celestial's pull ends
+func (do *DebugOverlay) gravity(renderer Renderer, camera Camera, celestials []Celestial) {
+	const spacing = 32 // pixels between the arrows
+	zoom := camera.Zoom()
+	for _, celestial := range celestials {
+		if well, ok := celestial.(GravityWell); ok && well.Mass() > 0 {
+			do.rangeCircle(renderer, camera.WorldToScreen(renderer, well.Coordinates()), well.Influence()*zoom, debugGravity)
+		}
+	}
+
+	screen := renderer.Bounds()
+	for x := screen.Min.X + spacing/2; x < screen.Max.X; x += spacing {
+		for y := screen.Min.Y + spacing/2; y < screen.Max.Y; y += spacing {
+			position := pixel.V(x, y)
+			acceleration := gravityAt(celestials, camera.ScreenToWorld(renderer, position))
+			if acceleration == pixel.ZV {
+				continue
+			}
+			// the pull goes from almost nothing to hundreds of pixels/s², so the arrows grow with its log
+			length := math.Min(spacing*0.8, 4*math.Log1p(acceleration.Len()))
+			tip := position.Add(acceleration.Unit().Scaled(length))
+			renderer.Line(position, tip, 1, debugGravity)
+			renderer.Polygon(arrowhead(tip, acceleration.Angle()-math.Pi/2, 2), 0, debugGravity)
+		}
+	}
+}
+
+// The bounds of an entity, turned with it
+func (do *DebugOverlay) bounds(renderer Renderer, entity Entity, position pixel.Vec, zoom float64) {
+	bounds := entity.Bounds()
+	// bounds are centered on the entity, like its sprite
+	corners := bounds.Moved(bounds.Center().Scaled(-1)).Vertices()
+	points := make([]pixel.Vec, len(corners))
+	for i, corner := range corners {
+		points[i] = position.Add(corner.Scaled(zoom).Rotated(entity.Angle()))
+	}
+	renderer.Polygon(points, 1, debugBounds)
+}
+
+// Ranges are huge, there is nothing to draw when the screen is inside the circle
+func (do *DebugOverlay) rangeCircle(renderer Renderer, center pixel.Vec, radius float64, color color.Color) {
+	screen := renderer.Bounds()
+	farthest := 0.0
+	for _, corner := range screen.Vertices() {
+		farthest = math.Max(farthest, corner.Sub(center).Len())
+	}
+	if farthest < radius {
+		return
+	}
+	renderer.Circle(center, radius, 1, color)
+}
+
+// The statistics and the frame-time graph go in the top left corner of the HUD
+func (do *DebugOverlay) Widgets() []HUDWidget {
+	if !do.enabled {
+		return nil
+	}
+	stats := NewTextWidget(AnchorTopLeft, func() []string {
+		last := do.frameTimes[(do.frame+debugFrames-1)%debugFrames]
+		return []string{
+			fmt.Sprintf("FPS:        %5.1f (%.1f ms)", do.FPS(), last*1000),
+			fmt.Sprintf("Entities:   %d", do.counts.entities),
+			fmt.Sprintf("Celestials: %d", do.counts.celestials),
+			fmt.Sprintf("Particles:  %d", do.counts.particles),
+			fmt.Sprintf("Goroutines: %d", do.goroutines()),
+		}
+	})
+	return []HUDWidget{stats, &frameGraph{do}}
+}
+
+// frameGraph shows the recorded frame times as bars, oldest first.
+// The line is the time of a frame at 60 FPS, bars that go over it are red.
+type frameGraph struct {
+	overlay *DebugOverlay
+}
+
+func (fg *frameGraph) Anchor() HUDAnchor {
+	return AnchorTopLeft
+}
+
+func (fg *frameGraph) Size(renderer Renderer) pixel.Vec {
+	return pixel.V(2*debugFrames, 50)
+}
+
+func (fg *frameGraph) Render(renderer Renderer, bounds pixel.Rect) {
+	const target, ceiling = 1.0 / 60, 2.0 / 60 // the graph tops out at 30 FPS
+	scale := bounds.H() / ceiling
+
+	frame := bounds.Vertices()
+	renderer.Polygon(frame[:], 1, debugGraph)
+	for i := 0; i < debugFrames; i++ {
+		dt := fg.overlay.frameTimes[(fg.overlay.frame+i)%debugFrames]
+		if dt == 0 {
+			continue
+		}
+		color := debugGraph
+		if dt > target {
+			color = debugGraphSlow
+		}
+		x := bounds.Min.X + float64(2*i) + 1.5 // the middle of a pixel
+		renderer.Line(pixel.V(x, bounds.Min.Y), pixel.V(x, bounds.Min.Y+math.Min(dt*scale, bounds.H())), 1, color)
+	}
+	y := bounds.Min.Y + target*scale
+	renderer.Line(pixel.V(bounds.Min.X, y), pixel.V(bounds.Max.X, y), 1, colornames.White)
+}
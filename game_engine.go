@@ -1,6 +1,7 @@
 package spacegame
 
 import (
+	"math"
 	"time"
 
 	"github.com/faiface/pixel"
@@ -15,15 +16,20 @@ type GameEngine struct {
 	scene       Scene
 	window      *pixelgl.Window // REPLACE WITH EVENT MANAGER!!
 	renderer    Renderer
+	recorder    *FrameRecorder
+	bounds      pixel.Rect // of the window when the scene last heard about it
+	windowed    pixel.Rect // of the window before it went fullscreen
 }
 
 // TODO: Options parameter
-func NewGame() GameEngine {
-	// TODO: Get bounds from monitor
+func NewGame() *GameEngine {
+	// Start in a window that covers most of the monitor
+	width, height := pixelgl.PrimaryMonitor().Size()
 	cfg := pixelgl.WindowConfig{
-		Title:  "Space Game!",
-		Bounds: pixel.R(0, 0, 1024, 768),
-		VSync:  true,
+		Title:     "Space Game!",
+		Bounds:    pixel.R(0, 0, math.Round(width*0.75), math.Round(height*0.75)),
+		VSync:     true,
+		Resizable: true,
 	}
 	window, err := pixelgl.NewWindow(cfg)
 	if err != nil {
@@ -52,14 +58,20 @@ func NewGame() GameEngine {
 
 	renderer := NewPixelWindowRenderer(window, resourceManager)
 
-	ge := GameEngine{
-		player:     player,
-		controller: NewPlayerController(window, player),
-		universe:   universe,
-		window:     window, // TODO: Event manager
-		renderer:   renderer,
-		scene:      NewSpaceScene(startSystem, player, renderer),
+	scene := NewSpaceScene(startSystem, player, renderer, time.Now().UnixNano())
+	scene.Arrive()
+
+	ge := &GameEngine{
+		player:   player,
+		universe: universe,
+		window:   window, // TODO: Event manager
+		renderer: renderer,
+		recorder: NewFrameRecorder("screenshots", 2),
+		scene:    scene,
+		bounds:   window.Bounds(),
 	}
+	// The engine handles its own actions and passes the rest on to the player
+	ge.controller = NewPlayerController(window, ge)
 
 	return ge
 }
@@ -75,12 +87,23 @@ func (ge *GameEngine) Run() {
 			break
 		}
 
-		go ge.controller.relay(dt)
+		// pixelgl has no resize events, so check the size every frame
+		if bounds := ge.window.Bounds(); bounds != ge.bounds {
+			ge.bounds = bounds
+			ge.scene.Resize(bounds)
+		}
+
+		// input is applied here, before the scene ticks, so nothing changes while the scene reads it
+		ge.controller.relay(dt)
+		if scene, ok := ge.scene.(Aimable); ok {
+			scene.Aim(ge.window.MousePosition())
+		}
 		ge.tick(dt)
 
 		// Render everything (refactor.. decouple)
 
 		ge.scene.Render()
+		ge.recorder.Frame(ge.renderer)
 
 		// Draw extra UI elements
 		// If extra UI elements...
@@ -96,3 +119,34 @@ func (ge *GameEngine) tick(dt float64) {
 	go ge.player.tick() // TODO: Move to scene, send scene info as parameter or otherwise find way to give player a context
 	ge.scene.tick(dt)
 }
+
+func (ge *GameEngine) Process(a pilotAction) {
+	switch a.key {
+	case actionScreenshot:
+		ge.recorder.Screenshot()
+	case actionCapture:
+		ge.recorder.ToggleCapture()
+	case actionFullscreen:
+		ge.toggleFullscreen()
+	case actionZoomIn, actionZoomOut, actionFreeLook, actionDebug, actionScaleUI, actionPanUp, actionPanDown, actionPanLeft, actionPanRight:
+		if scene, ok := ge.scene.(Controllable); ok {
+			scene.Process(a)
+		}
+	default:
+		ge.player.Process(a)
+	}
+}
+
+// Switch between fullscreen on the primary monitor and the window as it was before
+func (ge *GameEngine) toggleFullscreen() {
+	if ge.window.Monitor() != nil {
+		ge.window.SetMonitor(nil)
+		ge.window.SetBounds(ge.windowed)
+		return
+	}
+	ge.windowed = ge.window.Bounds()
+	monitor := pixelgl.PrimaryMonitor()
+	width, height := monitor.Size()
+	ge.window.SetMonitor(monitor)
+	ge.window.SetBounds(pixel.R(0, 0, width, height))
+}
@@ -0,0 +1,59 @@
+package spacegame
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	gravity          = 100 // the gravitational constant, pixels³ per tonne per second²
+	defaultInfluence = 16  // how many radii a celestial's pull reaches, unless it says otherwise
+	surfaceCap       = 1.5 // closer than this many radii the pull doesn't get any stronger, so ships can still land
+	influenceFade    = 0.1 // the pull fades out over the last part of the influence, so there is no bump at the edge
+)
+
+// Celestials with mass pull everything that moves toward them
+type GravityWell interface {
+	Entity
+	Radius() float64
+	Mass() float64
+	Influence() float64 // how far the pull reaches
+}
+
+// Bodies that gravity pulls on, it acts on them as a force until their next step
+type Attracted interface {
+	Mass() float64
+	ApplyForce(force pixel.Vec)
+}
+
+// The acceleration toward a gravity well, in pixels/s²
+func pull(well GravityWell, position pixel.Vec) pixel.Vec {
+	if well.Mass() <= 0 {
+		return pixel.ZV
+	}
+	offset := well.Coordinates().Sub(position)
+	distance := offset.Len()
+	if distance >= well.Influence() || distance == 0 {
+		return pixel.ZV
+	}
+
+	r := math.Max(distance, well.Radius()*surfaceCap)
+	acceleration := gravity * well.Mass() / (r * r)
+	fade := well.Influence() * (1 - influenceFade)
+	if distance > fade {
+		acceleration *= (well.Influence() - distance) / (well.Influence() - fade)
+	}
+	return offset.Scaled(acceleration / distance)
+}
+
+// The acceleration from every celestial that has a pull on position
+func gravityAt(celestials []Celestial, position pixel.Vec) pixel.Vec {
+	total := pixel.ZV
+	for _, celestial := range celestials {
+		if well, ok := celestial.(GravityWell); ok {
+			total = total.Add(pull(well, position))
+		}
+	}
+	return total
+}
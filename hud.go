@@ -0,0 +1,167 @@
+package spacegame
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/faiface/pixel"
+)
+
+// Screen corners that widgets can be anchored to
+type HUDAnchor int
+
+const (
+	AnchorTopLeft HUDAnchor = iota
+	AnchorTopRight
+	AnchorBottomLeft
+	AnchorBottomRight
+	AnchorFill // The widget fills the screen, its size is ignored
+)
+
+// A HUDWidget is a piece of the HUD. The HUD decides where it goes, based on its anchor and size.
+// The renderer is passed to Size so that widgets can measure their text.
+type HUDWidget interface {
+	Anchor() HUDAnchor
+	Size(renderer Renderer) pixel.Vec
+	Render(renderer Renderer, bounds pixel.Rect)
+}
+
+// Ship systems that contribute to the HUD implement HUDProvider
+type HUDProvider interface {
+	Widgets() []HUDWidget
+}
+
+// The HUD lays out widgets in the corners of the screen.
+// Widgets are stacked in the order they are given: downwards in the top corners and upwards in the bottom corners.
+type HUD struct {
+	margin  float64
+	spacing float64
+}
+
+func NewHUD() *HUD {
+	return &HUD{
+		margin:  30,
+		spacing: 10,
+	}
+}
+
+// Layout returns the bounds of each widget
+func (h *HUD) Layout(renderer Renderer, widgets []HUDWidget) []pixel.Rect {
+	var (
+		screen = renderer.Bounds()
+		layout = make([]pixel.Rect, len(widgets))
+		inner  = pixel.R(screen.Min.X+h.margin, screen.Min.Y+h.margin, screen.Max.X-h.margin, screen.Max.Y-h.margin)
+		offset = make(map[HUDAnchor]float64) // distance from the corner that is taken
+	)
+
+	for i, widget := range widgets {
+		anchor := widget.Anchor()
+		if anchor == AnchorFill {
+			layout[i] = inner
+			continue
+		}
+
+		var (
+			size = widget.Size(renderer)
+			min  pixel.Vec
+		)
+
+		switch anchor {
+		case AnchorTopLeft:
+			min = pixel.V(inner.Min.X, inner.Max.Y-offset[anchor]-size.Y)
+		case AnchorTopRight:
+			min = pixel.V(inner.Max.X-size.X, inner.Max.Y-offset[anchor]-size.Y)
+		case AnchorBottomLeft:
+			min = pixel.V(inner.Min.X, inner.Min.Y+offset[anchor])
+		case AnchorBottomRight:
+			min = pixel.V(inner.Max.X-size.X, inner.Min.Y+offset[anchor])
+		}
+
+		layout[i] = pixel.R(min.X, min.Y, min.X+size.X, min.Y+size.Y)
+		// hidden widgets have no size, they don't take up any space
+		if size != pixel.ZV {
+			offset[anchor] += size.Y + h.spacing
+		}
+	}
+
+	return layout
+}
+
+func (h *HUD) Render(renderer Renderer, widgets []HUDWidget) {
+	for i, bounds := range h.Layout(renderer, widgets) {
+		widgets[i].Render(renderer, bounds)
+	}
+}
+
+// A TextWidget shows a few lines of text, most widgets are built on it
+type TextWidget struct {
+	anchor HUDAnchor
+	lines  func() []string
+	style  TextStyle
+}
+
+func NewTextWidget(anchor HUDAnchor, lines func() []string) *TextWidget {
+	return &TextWidget{
+		anchor: anchor,
+		lines:  lines,
+		style:  HUDTextStyle,
+	}
+}
+
+func (tw *TextWidget) Anchor() HUDAnchor {
+	return tw.anchor
+}
+
+func (tw *TextWidget) Size(renderer Renderer) pixel.Vec {
+	return renderer.MeasureText(strings.Join(tw.lines(), "\n"), tw.style)
+}
+
+func (tw *TextWidget) Render(renderer Renderer, bounds pixel.Rect) {
+	// text is positioned by the baseline of its first line
+	lineHeight := renderer.MeasureText("", tw.style).Y
+	renderer.Text(strings.Join(tw.lines(), "\n"), pixel.V(bounds.Min.X, bounds.Max.Y-lineHeight), tw.style)
+}
+
+// gauge draws a bar like [#####-----] that is filled to value/max
+func gauge(value, max float64, width int) string {
+	filled := 0
+	if max > 0 {
+		filled = int(math.Round(float64(width) * math.Min(math.Max(value/max, 0), 1)))
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// heading converts a ship angle (0 is up, counterclockwise) to compass degrees (0 is up, clockwise)
+func heading(angle float64) float64 {
+	// angles are normalized to (-Pi, Pi], so this is always positive
+	return math.Mod(360-angle*180/math.Pi, 360)
+}
+
+// distance and closing speed (positive when approaching) from one entity to another
+func rangeTo(from, to Entity) (distance, closing float64) {
+	offset := to.Coordinates().Sub(from.Coordinates())
+	distance = offset.Len()
+	if distance == 0 {
+		return 0, 0
+	}
+	relative := to.Velocity().Sub(from.Velocity())
+	closing = 0 - relative.Dot(offset.Unit()) // avoid negative zero
+	return distance, closing
+}
+
+// A small triangle pointing in the direction of angle (0 is up)
+func arrowhead(position pixel.Vec, angle, size float64) []pixel.Vec {
+	return []pixel.Vec{
+		position.Add(pixel.V(0, size).Rotated(angle)),
+		position.Add(pixel.V(-size*0.7, -size*0.7).Rotated(angle)),
+		position.Add(pixel.V(size*0.7, -size*0.7).Rotated(angle)),
+	}
+}
+
+func formatDistance(distance float64) string {
+	if distance >= 10000 {
+		return fmt.Sprintf("%.1fk", distance/1000)
+	}
+	return fmt.Sprintf("%.0f", distance)
+}
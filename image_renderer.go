@@ -0,0 +1,273 @@
+package spacegame
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/faiface/pixel"
+)
+
+// ImageRenderer is a software Renderer that composites sprites into an image.RGBA.
+// It doesn't need a window (or a GPU), so it can be used for tests on headless machines and for tooling.
+// Coordinates follow pixel's convention: the origin is the bottom left corner of the image.
+// Clipped renderers draw on a sub-image, so their image may not start at 0, 0.
+type ImageRenderer struct {
+	image           *image.RGBA
+	resourceManager ResourceManager
+}
+
+func NewImageRenderer(width, height int, resourceManager ResourceManager) *ImageRenderer {
+	ir := &ImageRenderer{
+		image:           image.NewRGBA(image.Rect(0, 0, width, height)),
+		resourceManager: resourceManager,
+	}
+	ir.Clear()
+	return ir
+}
+
+// Image returns the image that is being rendered to
+func (ir *ImageRenderer) Image() *image.RGBA {
+	return ir.image
+}
+
+func (ir *ImageRenderer) Bounds() pixel.Rect {
+	size := ir.image.Bounds().Size()
+	return pixel.R(0, 0, float64(size.X), float64(size.Y))
+}
+
+func (ir *ImageRenderer) Capture() (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, ir.image.Bounds().Dx(), ir.image.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), ir.image, ir.image.Bounds().Min, draw.Src)
+	return img, nil
+}
+
+func (ir *ImageRenderer) Center() pixel.Vec {
+	return ir.Bounds().Center()
+}
+
+func (ir *ImageRenderer) Circle(center pixel.Vec, radius, thickness float64, color color.Color) {
+	outer, inner := radius, -1.0
+	if thickness > 0 {
+		outer, inner = radius+thickness/2, radius-thickness/2
+	}
+	area := pixel.R(center.X-outer, center.Y-outer, center.X+outer, center.Y+outer)
+	ir.fill(area, color, func(at pixel.Vec) bool {
+		distance := at.Sub(center).Len()
+		return distance <= outer && distance >= inner
+	})
+}
+
+func (ir *ImageRenderer) Clear() {
+	draw.Draw(ir.image, ir.image.Bounds(), image.NewUniform(colornames.Black), image.ZP, draw.Src)
+}
+
+// The clipped renderer draws on the same pixels, through a sub-image
+func (ir *ImageRenderer) Clip(rect pixel.Rect, within func(Renderer)) {
+	rect = rect.Norm()
+	var (
+		bounds = ir.image.Bounds()
+		height = bounds.Dy()
+		// the image's origin is the top left corner
+		area = image.Rect(int(rect.Min.X), height-int(rect.Max.Y), int(rect.Max.X), height-int(rect.Min.Y)).Add(bounds.Min)
+	)
+	within(&ImageRenderer{
+		image:           ir.image.SubImage(area).(*image.RGBA),
+		resourceManager: ir.resourceManager,
+	})
+}
+
+func (ir *ImageRenderer) Line(from, to pixel.Vec, thickness float64, color color.Color) {
+	half := math.Max(thickness, 1) / 2
+	area := pixel.R(from.X, from.Y, to.X, to.Y).Norm()
+	area = pixel.R(area.Min.X-half, area.Min.Y-half, area.Max.X+half, area.Max.Y+half)
+	ir.fill(area, color, func(at pixel.Vec) bool {
+		return segmentDistance(at, from, to) <= half
+	})
+}
+
+func (ir *ImageRenderer) MeasureText(txt string, style TextStyle) pixel.Vec {
+	return measureText(textFace(ir.resourceManager, style), txt, style)
+}
+
+func (ir *ImageRenderer) Polygon(points []pixel.Vec, thickness float64, color color.Color) {
+	if len(points) == 0 {
+		return
+	}
+	if thickness > 0 {
+		for i := range points {
+			ir.Line(points[i], points[(i+1)%len(points)], thickness, color)
+		}
+		return
+	}
+
+	area := pixel.R(points[0].X, points[0].Y, points[0].X, points[0].Y)
+	for _, point := range points[1:] {
+		area = area.Union(pixel.R(point.X, point.Y, point.X, point.Y))
+	}
+	ir.fill(area, color, func(at pixel.Vec) bool {
+		return insidePolygon(at, points)
+	})
+}
+
+func (ir *ImageRenderer) Render(renderable Entity, position pixel.Vec, scale float64) {
+	bounds := ir.Bounds()
+	if !visible(bounds, renderable, position, scale) {
+		return
+	}
+	if drawer, ok := renderable.(Drawer); ok {
+		drawer.Draw(ir, position, scale)
+		return
+	}
+
+	resource := ir.resourceManager.Resource(renderable)
+	picture := pixel.PictureDataFromPicture(resource.sprite.Picture())
+	frame := resource.sprite.Frame()
+	matrix := spriteMatrix(resource, renderable, position, scale)
+
+	// sprites are drawn centered around their origin, find the area they cover on the image
+	local := frame.Moved(frame.Center().Scaled(-1))
+	area := pixel.R(math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1))
+	for _, corner := range local.Vertices() {
+		projected := matrix.Project(corner)
+		area = area.Union(pixel.R(projected.X, projected.Y, projected.X, projected.Y))
+	}
+	area = area.Intersect(bounds)
+	if area.Area() == 0 {
+		return
+	}
+
+	height := ir.image.Bounds().Dy()
+	for y := int(math.Floor(area.Min.Y)); y < int(math.Ceil(area.Max.Y)); y++ {
+		for x := int(math.Floor(area.Min.X)); x < int(math.Ceil(area.Max.X)); x++ {
+			// sample the sprite at the center of the pixel (nearest neighbour)
+			at := matrix.Unproject(pixel.V(float64(x)+0.5, float64(y)+0.5)).Add(frame.Center())
+			if !frame.Contains(at) || !picture.Rect.Contains(at) {
+				continue
+			}
+			src := picture.Pix[picture.Index(at)]
+			if src.A == 0 {
+				continue
+			}
+			// the image's origin is the top left corner
+			ir.blend(x, height-1-y, src)
+		}
+	}
+}
+
+func (ir *ImageRenderer) ResourceManager() ResourceManager {
+	return ir.resourceManager
+}
+
+func (ir *ImageRenderer) Text(s string, position pixel.Vec, style TextStyle) error {
+	var src image.Image = image.White
+	if style.Color != nil {
+		src = image.NewUniform(style.Color)
+	}
+
+	face := textFace(ir.resourceManager, style)
+	drawer := font.Drawer{
+		Dst:  ir.image,
+		Src:  src,
+		Face: face,
+	}
+	bounds := ir.image.Bounds()
+	for _, line := range layoutText(face, s, position, style) {
+		// the image's origin is the top left corner
+		drawer.Dot = fixed.P(bounds.Min.X+int(line.dot.X), bounds.Min.Y+bounds.Dy()-int(line.dot.Y))
+		drawer.DrawString(line.text)
+	}
+	return nil
+}
+
+// Update does nothing, the image is always up to date
+func (ir *ImageRenderer) Update() {
+}
+
+// blend composites a premultiplied color over the pixel at x, y (image coordinates, from the image's top left corner)
+func (ir *ImageRenderer) blend(x, y int, src color.RGBA) {
+	min := ir.image.Bounds().Min
+	offset := ir.image.PixOffset(min.X+x, min.Y+y)
+	pix := ir.image.Pix[offset : offset+4]
+	inverse := 255 - uint32(src.A)
+	pix[0] = uint8(uint32(src.R) + uint32(pix[0])*inverse/255)
+	pix[1] = uint8(uint32(src.G) + uint32(pix[1])*inverse/255)
+	pix[2] = uint8(uint32(src.B) + uint32(pix[2])*inverse/255)
+	pix[3] = uint8(uint32(src.A) + uint32(pix[3])*inverse/255)
+}
+
+// fill blends color over every pixel in area whose center is inside the shape
+func (ir *ImageRenderer) fill(area pixel.Rect, c color.Color, inside func(pixel.Vec) bool) {
+	premultiplied := color.RGBAModel.Convert(c).(color.RGBA)
+	if premultiplied.A == 0 {
+		return
+	}
+	area = pixel.R(math.Floor(area.Min.X), math.Floor(area.Min.Y), math.Ceil(area.Max.X)+1, math.Ceil(area.Max.Y)+1)
+	area = area.Intersect(ir.Bounds())
+
+	height := ir.image.Bounds().Dy()
+	for y := int(area.Min.Y); y < int(area.Max.Y); y++ {
+		for x := int(area.Min.X); x < int(area.Max.X); x++ {
+			if inside(pixel.V(float64(x)+0.5, float64(y)+0.5)) {
+				ir.blend(x, height-1-y, premultiplied)
+			}
+		}
+	}
+}
+
+// distance from a point to the line segment between a and b
+func segmentDistance(point, a, b pixel.Vec) float64 {
+	segment := b.Sub(a)
+	length := segment.Dot(segment)
+	if length == 0 {
+		return point.Sub(a).Len()
+	}
+	t := math.Max(0, math.Min(1, point.Sub(a).Dot(segment)/length))
+	return point.Sub(a.Add(segment.Scaled(t))).Len()
+}
+
+// even-odd rule
+func insidePolygon(point pixel.Vec, polygon []pixel.Vec) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > point.Y) != (b.Y > point.Y) && point.X < (b.X-a.X)*(point.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// SystemThumbnail renders all celestials of a solar system into an image of the specified size.
+// The system is centered and its layout is shrunk to fit, but the celestials keep their size.
+func SystemThumbnail(system *SolarSystem, resourceManager ResourceManager, width, height int) *image.RGBA {
+	renderer := NewImageRenderer(width, height, resourceManager)
+	celestials := system.Celestials()
+	if len(celestials) == 0 {
+		return renderer.Image()
+	}
+
+	// find the extents of the system
+	extents := celestials[0].Bounds().Moved(celestials[0].Coordinates())
+	for _, c := range celestials[1:] {
+		extents = extents.Union(c.Bounds().Moved(c.Coordinates()))
+	}
+
+	bounds := renderer.Bounds()
+	scale := math.Min(bounds.W()/extents.W(), bounds.H()/extents.H())
+	if scale > 1 {
+		scale = 1
+	}
+
+	for _, c := range celestials {
+		offset := c.Coordinates().Sub(extents.Center()).Scaled(scale)
+		renderer.Render(c, renderer.Center().Add(offset), scale)
+	}
+
+	return renderer.Image()
+}
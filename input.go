@@ -18,6 +18,30 @@ const (
 	actionTargetNext  = "targetNext"
 	actionLand        = "targetLand"
 	actionClearTarget = "clearTarget"
+	actionScreenshot  = "screenshot"
+	actionCapture     = "capture"
+	actionRadarZoom   = "radarZoom"
+	actionSystemMap   = "systemMap"
+	actionZoomIn      = "zoomIn"
+	actionZoomOut     = "zoomOut"
+	actionFreeLook    = "freeLook"
+	actionDebug       = "debug"
+	actionFullscreen  = "fullscreen"
+	actionScaleUI     = "scaleUI"
+	actionPanUp       = "panUp"
+	actionPanDown     = "panDown"
+	actionPanLeft     = "panLeft"
+	actionPanRight    = "panRight"
+
+	actionApproach      = "approach"
+	actionIntercept     = "intercept"
+	actionMatchVelocity = "matchVelocity"
+	actionHoldPosition  = "holdPosition"
+	actionFlightAssist  = "flightAssist"
+	actionFire          = "fire"
+	actionLaunch        = "launch"
+	actionDecoy         = "decoy"
+	actionTurretMode    = "turretMode"
 )
 
 type Controllable interface {
@@ -53,6 +77,29 @@ func NewPlayerController(window *pixelgl.Window, entity Controllable) *Controlle
 	c.repeaters[actionTargetNext] = false
 	c.repeaters[actionLand] = false
     c.repeaters[actionClearTarget] = false
+	c.repeaters[actionScreenshot] = false
+	c.repeaters[actionCapture] = false
+	c.repeaters[actionRadarZoom] = false
+	c.repeaters[actionSystemMap] = false
+	c.repeaters[actionZoomIn] = false
+	c.repeaters[actionZoomOut] = false
+	c.repeaters[actionFreeLook] = false
+	c.repeaters[actionDebug] = false
+	c.repeaters[actionFullscreen] = false
+	c.repeaters[actionScaleUI] = false
+	c.repeaters[actionPanUp] = true
+	c.repeaters[actionPanDown] = true
+	c.repeaters[actionPanLeft] = true
+	c.repeaters[actionPanRight] = true
+	c.repeaters[actionApproach] = false
+	c.repeaters[actionIntercept] = false
+	c.repeaters[actionMatchVelocity] = false
+	c.repeaters[actionHoldPosition] = false
+	c.repeaters[actionFlightAssist] = false
+	c.repeaters[actionFire] = true
+	c.repeaters[actionLaunch] = false
+	c.repeaters[actionDecoy] = false
+	c.repeaters[actionTurretMode] = false
 
 	return c
 }
@@ -72,6 +119,15 @@ func (c *Controller) relay(dt float64) {
 			c.entity.Process(pilotAction{cmd, dt})
 		}
 	}
+
+	// each step of the mouse wheel zooms once
+	scroll := c.window.MouseScroll().Y
+	for ; scroll >= 1; scroll-- {
+		c.entity.Process(pilotAction{actionZoomIn, dt})
+	}
+	for ; scroll <= -1; scroll++ {
+		c.entity.Process(pilotAction{actionZoomOut, dt})
+	}
 }
 
 func (c *Controller) SetKey(key pixelgl.Button, action string) error {
@@ -98,4 +154,27 @@ func (c *Controller) ResetBindings() {
 	c.SetKey(pixelgl.KeyA, actionAlign)
 	c.SetKey(pixelgl.KeyL, actionLand)
     c.SetKey(pixelgl.KeyC, actionClearTarget)
+	c.SetKey(pixelgl.KeyF12, actionScreenshot)
+	c.SetKey(pixelgl.KeyF9, actionCapture)
+	c.SetKey(pixelgl.KeyZ, actionRadarZoom)
+	c.SetKey(pixelgl.KeyM, actionSystemMap)
+	c.SetKey(pixelgl.KeyEqual, actionZoomIn)
+	c.SetKey(pixelgl.KeyMinus, actionZoomOut)
+	c.SetKey(pixelgl.KeyF, actionFreeLook)
+	c.SetKey(pixelgl.KeyF3, actionDebug)
+	c.SetKey(pixelgl.KeyF11, actionFullscreen)
+	c.SetKey(pixelgl.KeyF10, actionScaleUI)
+	c.SetKey(pixelgl.KeyKP8, actionPanUp)
+	c.SetKey(pixelgl.KeyKP2, actionPanDown)
+	c.SetKey(pixelgl.KeyKP4, actionPanLeft)
+	c.SetKey(pixelgl.KeyKP6, actionPanRight)
+	c.SetKey(pixelgl.KeyP, actionApproach)
+	c.SetKey(pixelgl.KeyI, actionIntercept)
+	c.SetKey(pixelgl.KeyV, actionMatchVelocity)
+	c.SetKey(pixelgl.KeyH, actionHoldPosition)
+	c.SetKey(pixelgl.KeyX, actionFlightAssist)
+	c.SetKey(pixelgl.KeySpace, actionFire)
+	c.SetKey(pixelgl.KeyLeftControl, actionLaunch)
+	c.SetKey(pixelgl.KeyD, actionDecoy)
+	c.SetKey(pixelgl.KeyT, actionTurretMode)
 }
@@ -0,0 +1,141 @@
+package spacegame
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/colornames"
+
+	"github.com/faiface/pixel"
+)
+
+type MarkerKind int
+
+const (
+	MarkerTarget    MarkerKind = iota // selected by the scanner
+	MarkerHostile                     // belongs to an enemy faction
+	MarkerObjective                   // part of a mission
+)
+
+func (mk MarkerKind) color() color.Color {
+	switch mk {
+	case MarkerHostile:
+		return colornames.Red
+	case MarkerObjective:
+		return colornames.Cyan
+	}
+	return colornames.Yellow
+}
+
+// A Marker points out an entity to the pilot
+type Marker struct {
+	Entity Entity
+	Kind   MarkerKind
+}
+
+// MarkerRenderer draws a reticle around marked entities that are on the screen,
+// and an arrow at the edge of the screen pointing toward those that are not.
+type MarkerRenderer struct {
+	observer Entity  // distances and speeds are relative to the observer
+	margin   float64 // distance of the arrows from the edge of the screen
+}
+
+func NewMarkerRenderer(observer Entity) *MarkerRenderer {
+	return &MarkerRenderer{
+		observer: observer,
+		margin:   24,
+	}
+}
+
+func (mr *MarkerRenderer) Render(renderer Renderer, camera Camera, markers []Marker) {
+	screen := renderer.Bounds()
+	for _, marker := range markers {
+		position := camera.WorldToScreen(renderer, marker.Entity.Coordinates())
+		if visible(screen, marker.Entity, position, camera.Zoom()) {
+			mr.reticle(renderer, marker, position, camera.Zoom())
+		} else {
+			mr.arrow(renderer, marker, position)
+		}
+	}
+}
+
+// Brackets in the corners around the entity, targets are labeled
+func (mr *MarkerRenderer) reticle(renderer Renderer, marker Marker, position pixel.Vec, zoom float64) {
+	var (
+		bounds = marker.Entity.Bounds()
+		size   = math.Max(bounds.W(), bounds.H())*zoom/2 + 6
+		arm    = size / 3
+		color  = marker.Kind.color()
+	)
+
+	for _, corner := range []pixel.Vec{pixel.V(-1, -1), pixel.V(-1, 1), pixel.V(1, 1), pixel.V(1, -1)} {
+		at := position.Add(corner.Scaled(size))
+		renderer.Line(at, at.Sub(pixel.V(corner.X*arm, 0)), 1, color)
+		renderer.Line(at, at.Sub(pixel.V(0, corner.Y*arm)), 1, color)
+	}
+
+	if marker.Kind == MarkerTarget {
+		style := HUDTextStyle.WithColor(color)
+		lineHeight := renderer.MeasureText("", style).Y
+		renderer.Text(mr.label(marker.Entity), position.Add(pixel.V(size+6, size-lineHeight/2)), style)
+	}
+}
+
+// An arrow at the edge of the screen, pointing from the center toward the entity
+func (mr *MarkerRenderer) arrow(renderer Renderer, marker Marker, position pixel.Vec) {
+	screen := renderer.Bounds()
+	inset := pixel.R(screen.Min.X+mr.margin, screen.Min.Y+mr.margin, screen.Max.X-mr.margin, screen.Max.Y-mr.margin)
+	center := inset.Center()
+	direction := position.Sub(center)
+	if direction.Len() == 0 {
+		return
+	}
+
+	edge := edgePoint(inset, center, direction)
+	// arrowheads point up at angle 0
+	renderer.Polygon(arrowhead(edge, direction.Angle()-math.Pi/2, 8), 0, marker.Kind.color())
+
+	if marker.Kind == MarkerTarget {
+		distance, _ := rangeTo(mr.observer, marker.Entity)
+		style := HUDTextStyle.WithAlign(AlignCenter)
+		lineHeight := renderer.MeasureText("", style).Y
+		// keep the label on the screen side of the arrow
+		offset := direction.Unit().Scaled(-24).Sub(pixel.V(0, lineHeight/3))
+		renderer.Text(formatDistance(distance), edge.Add(offset), style)
+	}
+}
+
+func (mr *MarkerRenderer) label(entity Entity) string {
+	distance, closing := rangeTo(mr.observer, entity)
+	return fmt.Sprintf("%s\n%s %+.2f", entity.Name(), formatDistance(distance), closing)
+}
+
+// edgePoint finds where the ray from origin in direction leaves rect
+func edgePoint(rect pixel.Rect, origin, direction pixel.Vec) pixel.Vec {
+	t := math.Inf(1)
+	if direction.X > 0 {
+		t = math.Min(t, (rect.Max.X-origin.X)/direction.X)
+	} else if direction.X < 0 {
+		t = math.Min(t, (rect.Min.X-origin.X)/direction.X)
+	}
+	if direction.Y > 0 {
+		t = math.Min(t, (rect.Max.Y-origin.Y)/direction.Y)
+	} else if direction.Y < 0 {
+		t = math.Min(t, (rect.Min.Y-origin.Y)/direction.Y)
+	}
+	return origin.Add(direction.Scaled(t))
+}
+
+// Entities are hostile when their faction dislikes the observer's faction
+func hostile(observer, entity interface{}) bool {
+	a, ok := observer.(interface{ Faction() Faction })
+	if !ok || a.Faction() == nil {
+		return false
+	}
+	b, ok := entity.(interface{ Faction() Faction })
+	if !ok || b.Faction() == nil {
+		return false
+	}
+	return b.Faction().Relationship(a.Faction()) < 0
+}
@@ -0,0 +1,204 @@
+package spacegame
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	lockFalloff   = 1000 // pixels, a lock on a target this far away takes twice as long
+	seekerCone    = 0.5  // radians to either side of a missile's heading that its seeker sees decoys in
+	decoyLifetime = 4    // seconds
+)
+
+// Guidance steers a missile toward its target, for as long as it has fuel
+type Guidance struct {
+	Target   Entity
+	Thrust   float64 // pixels per second², while the fuel lasts
+	MaxSpeed float64 // pixels per second, the motor doesn't push the missile any faster
+	TurnRate float64 // radians per second
+	Fuel     float64 // seconds of thrust left
+}
+
+// steer turns the missile toward where its target is going to be, and burns fuel.
+// A decoy in front of the missile that is closer than its target takes the target's place.
+func (g *Guidance) steer(missile *Projectile, decoys []*Projectile, dt float64) {
+	// a decoy that burnt out is gone, its place in the pool may be another projectile by now
+	if decoy, ok := g.Target.(*Projectile); ok && (decoy.dead || !decoy.shot.Decoy || decoy.age >= decoy.shot.Lifetime) {
+		g.Target = nil
+	}
+	if g.Fuel <= 0 || g.Target == nil {
+		return
+	}
+	velocity := missile.Velocity()
+	heading := velocity.Angle()
+	for _, decoy := range decoys {
+		offset := decoy.Coordinates().Sub(missile.Coordinates())
+		if offset.Len() < g.Target.Coordinates().Sub(missile.Coordinates()).Len() && math.Abs(normalizeAngle(offset.Angle()-heading)) < seekerCone {
+			g.Target = decoy
+		}
+	}
+
+	// lead the target by the time it takes to get there
+	offset := g.Target.Coordinates().Sub(missile.Coordinates())
+	closing := math.Max(velocity.Len(), 1)
+	aim := offset.Add(g.Target.Velocity().Sub(velocity).Scaled(offset.Len() / closing))
+	turn := normalizeAngle(aim.Angle() - heading)
+	turn = math.Max(-g.TurnRate*dt, math.Min(g.TurnRate*dt, turn))
+	velocity = velocity.Rotated(turn)
+
+	speed := math.Min(g.MaxSpeed, velocity.Len()+g.Thrust*dt)
+	missile.shot.Velocity = velocity.Unit().Scaled(math.Max(speed, velocity.Len()))
+	g.Fuel -= dt
+}
+
+// The launcher fires missiles at the scanner's target once it has a lock on it, and drops decoys.
+// Missiles and decoys are used up, what's left is saved with the ship.
+type ShipLauncher struct {
+	Damage      float64
+	LaunchSpeed float64 // pixels per second, on top of the ship's velocity
+	Thrust      float64 // of the missiles, pixels per second²
+	MaxSpeed    float64 // pixels per second
+	TurnRate    float64 // radians per second
+	Fuel        float64 // seconds of thrust
+	Lifetime    float64 // seconds before a missile self-destructs
+	LockTime    float64 // seconds to lock on a target close by, with a scanner of accuracy 100
+	Reload      float64 // seconds between launches
+	Ammo        int
+	Decoys      int     `json:",omitempty"`
+	Mass        float64 `json:",omitempty"` // tonnes
+	ship        *Ship
+	target      Entity  // that the launcher is locking on
+	lock        float64 // seconds spent locking on the target
+	reload      float64 // seconds until the next missile is ready
+	launch      bool    // the pilot wants to launch, since the last update
+	decoy       bool    // or to drop a decoy
+}
+
+func (sl ShipLauncher) Name() string {
+	return "launcher"
+}
+
+func (sl *ShipLauncher) Activate(command pilotAction) {
+	switch command.key {
+	case actionLaunch:
+		sl.launch = true
+	case actionDecoy:
+		sl.decoy = true
+	}
+}
+
+func (sl *ShipLauncher) Install(ship *Ship) {
+	sl.ship = ship
+}
+
+func (sl *ShipLauncher) weight() float64 {
+	return sl.Mass
+}
+
+func (sl *ShipLauncher) scanner() *ShipScanner {
+	scanner, _ := sl.ship.systems["scanner"].(*ShipScanner)
+	return scanner
+}
+
+// lockTime is how long a lock on target takes, worse scanners and farther targets take longer.
+// It's infinite without a scanner, or when the target is out of its range.
+func (sl *ShipLauncher) lockTime(target Entity) float64 {
+	scanner := sl.scanner()
+	if scanner == nil || scanner.Accuracy <= 0 {
+		return math.Inf(1)
+	}
+	distance := target.Coordinates().Sub(sl.ship.Coordinates()).Len()
+	if distance > scanner.Range {
+		return math.Inf(1)
+	}
+	return sl.LockTime * 100 / scanner.Accuracy * (1 + distance/lockFalloff)
+}
+
+// The target the launcher has a lock on, if any
+func (sl *ShipLauncher) Locked() Entity {
+	if sl.target == nil || sl.lock < sl.lockTime(sl.target) {
+		return nil
+	}
+	return sl.target
+}
+
+// Update keeps locking on the scanner's target, and launches what the pilot asked for
+func (sl *ShipLauncher) Update(info SceneInformation) {
+	sl.reload = math.Max(0, sl.reload-info.Elapsed)
+
+	var target Entity
+	if scanner := sl.scanner(); scanner != nil {
+		target = scanner.Target()
+	}
+	if target != sl.target {
+		sl.target, sl.lock = target, 0
+	}
+	if target != nil && !math.IsInf(sl.lockTime(target), 1) {
+		sl.lock += info.Elapsed
+	} else {
+		sl.lock = 0
+	}
+
+	launch, decoy := sl.launch, sl.decoy
+	sl.launch, sl.decoy = false, false
+	if info.Projectiles == nil {
+		return
+	}
+	if locked := sl.Locked(); launch && locked != nil && sl.reload == 0 && sl.Ammo > 0 {
+		sl.fire(info.Projectiles, locked)
+	}
+	if decoy && sl.Decoys > 0 {
+		sl.Decoys--
+		// decoys are thrown out the back, and drift away from the ship
+		back := pixel.V(0, -sl.ship.Bounds().H()/2).Rotated(sl.ship.Angle())
+		info.Projectiles.Fire(Shot{
+			Owner:    sl.ship,
+			Position: sl.ship.Coordinates().Add(back),
+			Velocity: sl.ship.Velocity().Add(back.Unit().Scaled(60)),
+			Lifetime: decoyLifetime,
+			Decoy:    true,
+		})
+	}
+}
+
+func (sl *ShipLauncher) fire(projectiles *ProjectilePool, target Entity) {
+	sl.Ammo--
+	sl.reload = sl.Reload
+
+	// ships point up at angle 0, missiles come off the rails under the nose
+	heading := pixel.V(0, 1).Rotated(sl.ship.Angle())
+	projectiles.Fire(Shot{
+		Owner:    sl.ship,
+		Position: sl.ship.Coordinates().Add(heading.Scaled(sl.ship.Bounds().H() / 2)),
+		Velocity: sl.ship.Velocity().Add(heading.Scaled(sl.LaunchSpeed)),
+		Damage:   sl.Damage,
+		Lifetime: sl.Lifetime,
+		Guidance: &Guidance{
+			Target:   target,
+			Thrust:   sl.Thrust,
+			MaxSpeed: sl.MaxSpeed,
+			TurnRate: sl.TurnRate,
+			Fuel:     sl.Fuel,
+		},
+	})
+}
+
+// The launcher shows its missiles, and how the lock is coming along
+func (sl *ShipLauncher) Widgets() []HUDWidget {
+	return []HUDWidget{NewTextWidget(AnchorBottomLeft, func() []string {
+		lock := "No target"
+		if sl.target != nil {
+			lock = gauge(sl.lock, sl.lockTime(sl.target), 10)
+			if sl.Locked() != nil {
+				lock = "Locked"
+			}
+		}
+		return []string{
+			fmt.Sprintf("Missiles: %d  Decoys: %d", sl.Ammo, sl.Decoys),
+			fmt.Sprintf("Lock:     %s", lock),
+		}
+	})}
+}
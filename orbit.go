@@ -0,0 +1,55 @@
+package spacegame
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// OrbitConfig describes the Kepler orbit of a celestial around its parent.
+// Orbits are worked out from the time, not integrated, so the same time always puts a celestial in the same place.
+type OrbitConfig struct {
+	Parent        string  `json:",omitempty"` // the name of the celestial orbited, without one the celestial orbits its coordinates
+	SemiMajorAxis float64 // pixels, half of the orbit's longest diameter
+	Eccentricity  float64 `json:",omitempty"` // 0 is a circle, up to (but not including) 1 for long ellipses
+	Period        float64 // seconds to go round once, negative periods go clockwise
+	Phase         float64 `json:",omitempty"` // how far round the orbit the celestial is at time 0, from 0 to 1
+	Periapsis     float64 `json:",omitempty"` // radians, the direction of the closest point to the parent
+}
+
+// The position and velocity relative to the parent at time t
+func (o *OrbitConfig) At(t float64) (position, velocity pixel.Vec) {
+	if o.Period == 0 {
+		return pixel.V(o.SemiMajorAxis, 0).Rotated(o.Periapsis), pixel.ZV
+	}
+	e := math.Max(0, math.Min(o.Eccentricity, 0.99))
+	motion := 2 * math.Pi / o.Period // the mean motion, radians per second
+	// the mean anomaly, wrapped in one go however long the game has been running
+	mean := math.Remainder(motion*t+2*math.Pi*o.Phase, 2*math.Pi)
+	anomaly := eccentricAnomaly(mean, e)
+
+	sin, cos := math.Sincos(anomaly)
+	minor := o.SemiMajorAxis * math.Sqrt(1-e*e)
+	position = pixel.V(o.SemiMajorAxis*(cos-e), minor*sin)
+
+	// how fast the eccentric anomaly changes, d/dt of Kepler's equation
+	rate := motion / (1 - e*cos)
+	velocity = pixel.V(-o.SemiMajorAxis*sin*rate, minor*cos*rate)
+	return position.Rotated(o.Periapsis), velocity.Rotated(o.Periapsis)
+}
+
+// eccentricAnomaly solves Kepler's equation M = E - e sin E for E with Newton's method
+func eccentricAnomaly(mean, e float64) float64 {
+	anomaly := mean
+	if e > 0.8 {
+		anomaly = math.Pi // a better start for long ellipses
+	}
+	for i := 0; i < 16; i++ {
+		step := (anomaly - e*math.Sin(anomaly) - mean) / (1 - e*math.Cos(anomaly))
+		anomaly -= step
+		if math.Abs(step) < 1e-12 {
+			break
+		}
+	}
+	return anomaly
+}
@@ -0,0 +1,234 @@
+package spacegame
+
+import (
+	"encoding/json"
+	"image/color"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+
+	"github.com/faiface/pixel"
+)
+
+// A ColorKey is the color of a particle at a point in its life, from 0 (born) to 1 (dead)
+type ColorKey struct {
+	At         float64
+	R, G, B, A uint8
+}
+
+// A SizeKey is the size of a particle at a point in its life, from 0 (born) to 1 (dead)
+type SizeKey struct {
+	At   float64
+	Size float64
+}
+
+// EmitterConfig describes a kind of particle and how it is emitted, they live in data/resources/effects
+type EmitterConfig struct {
+	Name            string
+	Rate            float64 // particles per second while emitting
+	Burst           int     // particles emitted at once by a burst
+	LifetimeMin     float64 // seconds
+	LifetimeMax     float64
+	SpeedMin        float64 // pixels per second
+	SpeedMax        float64
+	Spread          float64 // half the angle of the cone particles are emitted in, Pi is all around
+	InheritVelocity float64 // how much of the emitter's velocity the particles keep
+	Drag            float64 // fraction of the velocity lost per second
+	Colors          []ColorKey
+	Sizes           []SizeKey // radius in pixels, or the scale of the sprite
+	Sprite          string    // a resource to draw instead of a circle, it is not colored
+}
+
+func LoadEmitterConfig(path string) (*EmitterConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var config EmitterConfig
+	if err := json.NewDecoder(file).Decode(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (ec *EmitterConfig) color(t float64) color.RGBA {
+	if len(ec.Colors) == 0 {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	a, b, f := keyframes(len(ec.Colors), func(i int) float64 { return ec.Colors[i].At }, t)
+	ca, cb := ec.Colors[a], ec.Colors[b]
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f))
+	}
+	// premultiplied, like every color.Color
+	alpha := mix(ca.A, cb.A)
+	premultiply := func(c uint8) uint8 {
+		return uint8(uint16(c) * uint16(alpha) / 255)
+	}
+	return color.RGBA{premultiply(mix(ca.R, cb.R)), premultiply(mix(ca.G, cb.G)), premultiply(mix(ca.B, cb.B)), alpha}
+}
+
+func (ec *EmitterConfig) size(t float64) float64 {
+	if len(ec.Sizes) == 0 {
+		return 1
+	}
+	a, b, f := keyframes(len(ec.Sizes), func(i int) float64 { return ec.Sizes[i].At }, t)
+	return ec.Sizes[a].Size + (ec.Sizes[b].Size-ec.Sizes[a].Size)*f
+}
+
+// keyframes finds the two keys around t, and how far t is between them
+func keyframes(count int, at func(int) float64, t float64) (int, int, float64) {
+	if t <= at(0) {
+		return 0, 0, 0
+	}
+	for i := 1; i < count; i++ {
+		if t < at(i) {
+			return i - 1, i, (t - at(i-1)) / (at(i) - at(i-1))
+		}
+	}
+	return count - 1, count - 1, 0
+}
+
+type particle struct {
+	config   *EmitterConfig
+	sprite   Entity
+	position pixel.Vec
+	velocity pixel.Vec
+	age      float64
+	lifetime float64
+}
+
+// The ParticleSystem owns every particle in a scene.
+// Particles live in a fixed pool, when it is full new particles are dropped rather than allocated.
+// Ships emit particles while the scene updates them concurrently, so emitting is safe from any goroutine.
+type ParticleSystem struct {
+	resourceManager ResourceManager
+	configs         map[string]*EmitterConfig
+	sprites         map[string]Entity
+	particles       []particle // the live particles are at the front
+	alive           int
+	rng             *rand.Rand
+	lock            sync.Mutex
+}
+
+func NewParticleSystem(resourceManager ResourceManager, capacity int, seed int64) *ParticleSystem {
+	return &ParticleSystem{
+		resourceManager: resourceManager,
+		configs:         make(map[string]*EmitterConfig),
+		sprites:         make(map[string]Entity),
+		particles:       make([]particle, capacity),
+		rng:             rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (ps *ParticleSystem) config(name string) *EmitterConfig {
+	config, ok := ps.configs[name]
+	if ok {
+		return config
+	}
+	config, err := ps.resourceManager.Emitter(name)
+	if err != nil {
+		log.Println("Could not emit particles:", err)
+	}
+	// remember failures too, so they are only logged once
+	ps.configs[name] = config
+	if config != nil && config.Sprite != "" {
+		ps.sprites[name] = NewBaseEntity(config.Sprite, pixel.R(0, 0, 1, 1))
+	}
+	return config
+}
+
+// Emit particles from a continuous emitter for duration seconds.
+// Particles go out in a cone around angle, 0 is up like everything else.
+func (ps *ParticleSystem) Emit(name string, position, velocity pixel.Vec, angle, duration float64) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	config := ps.config(name)
+	if config == nil {
+		return
+	}
+	// the fraction of a particle is emitted with that probability
+	count := int(config.Rate*duration + ps.rng.Float64())
+	ps.spawn(name, config, count, position, velocity, angle)
+}
+
+// Burst emits the emitter's burst of particles all at once
+func (ps *ParticleSystem) Burst(name string, position, velocity pixel.Vec) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	config := ps.config(name)
+	if config == nil {
+		return
+	}
+	ps.spawn(name, config, config.Burst, position, velocity, 0)
+}
+
+func (ps *ParticleSystem) spawn(name string, config *EmitterConfig, count int, position, velocity pixel.Vec, angle float64) {
+	sprite := ps.sprites[name]
+	for i := 0; i < count && ps.alive < len(ps.particles); i++ {
+		direction := angle + (ps.rng.Float64()*2-1)*config.Spread
+		speed := config.SpeedMin + ps.rng.Float64()*(config.SpeedMax-config.SpeedMin)
+		ps.particles[ps.alive] = particle{
+			config:   config,
+			sprite:   sprite,
+			position: position,
+			velocity: pixel.V(0, speed).Rotated(direction).Add(velocity.Scaled(config.InheritVelocity)),
+			lifetime: config.LifetimeMin + ps.rng.Float64()*(config.LifetimeMax-config.LifetimeMin),
+		}
+		ps.alive++
+	}
+}
+
+// Update ages and moves every particle, the dead are replaced by the last live particle
+func (ps *ParticleSystem) Update(dt float64) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	for i := 0; i < ps.alive; {
+		p := &ps.particles[i]
+		p.age += dt
+		if p.age >= p.lifetime {
+			ps.alive--
+			ps.particles[i] = ps.particles[ps.alive]
+			continue
+		}
+		p.velocity = p.velocity.Scaled(math.Max(0, 1-p.config.Drag*dt))
+		p.position = p.position.Add(p.velocity.Scaled(dt))
+		i++
+	}
+}
+
+// The number of live particles
+func (ps *ParticleSystem) Count() int {
+	return ps.alive
+}
+
+// All particles are drawn by a single command on the effects layer
+func (ps *ParticleSystem) Submit(queue *RenderQueue, camera Camera) {
+	queue.Submit(LayerEffects, 0, func(renderer Renderer) {
+		ps.lock.Lock()
+		defer ps.lock.Unlock()
+
+		view := camera.View()
+		screen := renderer.Bounds()
+		for _, p := range ps.particles[:ps.alive] {
+			t := p.age / p.lifetime
+			position := view.WorldToScreen(renderer, p.position)
+			size := p.config.size(t) * view.Zoom
+			if p.sprite != nil {
+				renderer.Render(p.sprite, position, size)
+				continue
+			}
+			if size < 0.5 || !screen.Contains(position) {
+				continue
+			}
+			renderer.Circle(position, size, 0, p.config.color(t))
+		}
+	})
+}
@@ -1,10 +1,77 @@
 package spacegame
 
 import (
-	"log"
 	"math"
+
+	"github.com/faiface/pixel"
 )
 
+// The physics run at a fixed timestep, however fast the frames are
+const physicsStep = 1.0 / 120
+
+// If the game falls this far behind it gives up catching up rather than freezing
+const maxPhysicsLag = 0.25
+
+// A Physical is an entity that moves by itself, Step advances it by dt seconds
+type Physical interface {
+	Step(dt float64)
+}
+
+// A body is moved by the forces acting on it, with semi-implicit Euler integration.
+// Forces and torques act until the next step, impulses change the velocity right away.
+// Distances are in pixels, time in seconds and mass in tonnes.
+type body struct {
+	coordinates     pixel.Vec
+	velocity        pixel.Vec // pixels per second
+	angle           float64   // radians, 0 is up
+	angularVelocity float64   // radians per second, counterclockwise
+	mass            float64
+	inertia         float64 // moment of inertia, tonne pixels²
+	force           pixel.Vec
+	torque          float64
+}
+
+// The moment of inertia of a solid box of mass, spinning around its center
+func boxInertia(mass float64, size pixel.Vec) float64 {
+	return mass * (size.X*size.X + size.Y*size.Y) / 12
+}
+
+func (b *body) ApplyForce(force pixel.Vec) {
+	b.force = b.force.Add(force)
+}
+
+func (b *body) ApplyTorque(torque float64) {
+	b.torque += torque
+}
+
+func (b *body) ApplyImpulse(impulse pixel.Vec) {
+	if b.mass <= 0 {
+		return
+	}
+	b.velocity = b.velocity.Add(impulse.Scaled(1 / b.mass))
+}
+
+func (b *body) ApplyAngularImpulse(impulse float64) {
+	if b.inertia <= 0 {
+		return
+	}
+	b.angularVelocity += impulse / b.inertia
+}
+
+// Step integrates the velocity first and moves with the new velocity, which keeps orbits stable
+func (b *body) Step(dt float64) {
+	if b.mass > 0 {
+		b.velocity = b.velocity.Add(b.force.Scaled(dt / b.mass))
+	}
+	if b.inertia > 0 {
+		b.angularVelocity += b.torque * dt / b.inertia
+	}
+	b.coordinates = b.coordinates.Add(b.velocity.Scaled(dt))
+	b.angle = normalizeAngle(b.angle + b.angularVelocity*dt)
+
+	b.force, b.torque = pixel.ZV, 0
+}
+
 func normalizeAngle(rads float64) float64 {
 	var out float64 = rads
 	for out > math.Pi {
@@ -13,8 +80,5 @@ func normalizeAngle(rads float64) float64 {
 	for out <= -math.Pi {
 		out = out + 2*math.Pi
 	}
-	if out != rads {
-		log.Printf("Normalized %f to %f\n", rads, out)
-	}
 	return out
 }
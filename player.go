@@ -1,7 +1,5 @@
 package spacegame
 
-import "fmt"
-
 type Player struct {
 	name    string
 	ship    PilotableShip
@@ -38,7 +36,6 @@ func (p *Player) Ship() *Ship {
 	)
 	if ship, ok = p.ship.(*Ship); !ok {
 		panic("Wrong type for player ship")
-		return nil
 	}
 	return ship
 }
@@ -60,7 +57,6 @@ func (p *Player) tick() {
 func (p *Player) Process(a pilotAction) {
 	switch a.key {
 	default:
-		fmt.Printf("Processing action %v\n", a)
 		p.ship.Process(a)
 	}
 }
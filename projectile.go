@@ -0,0 +1,213 @@
+package spacegame
+
+import (
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/faiface/pixel"
+)
+
+// Bolts are drawn as a streak this long behind them, at zoom 1
+const boltLength = 8
+
+var (
+	boltColor    = color.RGBA{255, 220, 120, 255}
+	missileColor = color.RGBA{220, 220, 230, 255}
+	decoyColor   = color.RGBA{255, 255, 200, 255}
+)
+
+// A Shot is what a weapon fires, the pool turns it into a projectile
+type Shot struct {
+	Owner    Entity // a projectile doesn't hit the ship that fired it
+	Position pixel.Vec
+	Velocity pixel.Vec // the ship's velocity and the projectile's own
+	Damage   float64
+	Lifetime float64   // seconds
+	Guidance *Guidance // missiles steer themselves, other projectiles fly straight
+	Decoy    bool      // decoys lure missiles away, and set them off
+}
+
+// A Projectile flies straight until it hits something or runs out of time
+type Projectile struct {
+	shot Shot
+	age  float64
+	dead bool // hit something, it goes back to the pool after the step
+}
+
+func (p *Projectile) Name() string {
+	return "Projectile"
+}
+
+// Projectiles point where they are going, up at angle 0 like ships
+func (p *Projectile) Angle() float64 {
+	return p.shot.Velocity.Angle() - math.Pi/2
+}
+
+func (p *Projectile) Bounds() pixel.Rect {
+	return pixel.R(-1, -1, 1, 1)
+}
+
+func (p *Projectile) Coordinates() pixel.Vec {
+	return p.shot.Position
+}
+
+func (p *Projectile) Velocity() pixel.Vec {
+	return p.shot.Velocity
+}
+
+func (p *Projectile) Translate(by pixel.Vec) {
+	p.shot.Position = p.shot.Position.Add(by)
+}
+
+// The ship that fired it
+func (p *Projectile) Owner() Entity {
+	return p.shot.Owner
+}
+
+// How much damage it does to what it hits
+func (p *Projectile) Damage() float64 {
+	return p.shot.Damage
+}
+
+func (p *Projectile) RenderLayer() RenderLayer {
+	return LayerProjectiles
+}
+
+func (p *Projectile) Shape() Shape {
+	return CircleShape{p.shot.Position, 2}
+}
+
+// Missiles can be shot down, so they're on their own layer
+func (p *Projectile) CollisionLayer() CollisionLayer {
+	if p.shot.Guidance != nil {
+		return CollisionMissiles
+	}
+	return CollisionProjectiles
+}
+
+// Projectiles fly through each other, but not through missiles, and decoys only catch missiles
+func (p *Projectile) CollisionMask() CollisionLayer {
+	switch {
+	case p.shot.Decoy:
+		return CollisionMissiles
+	case p.shot.Guidance != nil:
+		return CollisionAll &^ CollisionMissiles
+	}
+	return CollisionAll &^ CollisionProjectiles
+}
+
+// Whether a missile's motor is still burning
+func (p *Projectile) Burning() bool {
+	return p.shot.Guidance != nil && p.shot.Guidance.Fuel > 0
+}
+
+// The ProjectilePool owns every projectile in a scene, like the ParticleSystem owns the particles.
+// Projectiles keep their place in the pool while they fly, so they can be told apart in contacts.
+// Ships fire while the scene updates them concurrently, so firing is safe from any goroutine.
+type ProjectilePool struct {
+	projectiles []Projectile
+	live        []*Projectile
+	free        []*Projectile
+	lock        sync.Mutex
+}
+
+func NewProjectilePool(capacity int) *ProjectilePool {
+	pp := &ProjectilePool{
+		projectiles: make([]Projectile, capacity),
+	}
+	for i := range pp.projectiles {
+		pp.free = append(pp.free, &pp.projectiles[i])
+	}
+	return pp
+}
+
+// Fire a shot, when the pool is empty it is dropped
+func (pp *ProjectilePool) Fire(shot Shot) {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	if len(pp.free) == 0 {
+		return
+	}
+	projectile := pp.free[len(pp.free)-1]
+	pp.free = pp.free[:len(pp.free)-1]
+	*projectile = Projectile{shot: shot}
+	pp.live = append(pp.live, projectile)
+}
+
+// Step moves the projectiles, the ones that hit something or ran out of time go back to the pool
+func (pp *ProjectilePool) Step(dt float64) {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	var decoys []*Projectile
+	for _, projectile := range pp.live {
+		if projectile.shot.Decoy && !projectile.dead {
+			decoys = append(decoys, projectile)
+		}
+	}
+
+	live := pp.live[:0]
+	for _, projectile := range pp.live {
+		projectile.age += dt
+		if projectile.dead || projectile.age >= projectile.shot.Lifetime {
+			pp.free = append(pp.free, projectile)
+			continue
+		}
+		if guidance := projectile.shot.Guidance; guidance != nil {
+			guidance.steer(projectile, decoys, dt)
+		}
+		projectile.Translate(projectile.Velocity().Scaled(dt))
+		live = append(live, projectile)
+	}
+	pp.live = live
+}
+
+// Remove takes a projectile out of the fight, it goes back to the pool on the next step
+func (pp *ProjectilePool) Remove(projectile *Projectile) {
+	projectile.dead = true
+}
+
+// The projectiles that are flying, and haven't hit anything yet
+func (pp *ProjectilePool) Projectiles() []*Projectile {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	var projectiles []*Projectile
+	for _, projectile := range pp.live {
+		if !projectile.dead {
+			projectiles = append(projectiles, projectile)
+		}
+	}
+	return projectiles
+}
+
+// The number of projectiles in flight
+func (pp *ProjectilePool) Count() int {
+	return len(pp.Projectiles())
+}
+
+// All projectiles are drawn by a single command on the projectile layer
+func (pp *ProjectilePool) Submit(queue *RenderQueue, camera Camera) {
+	projectiles := pp.Projectiles()
+	queue.Submit(LayerProjectiles, 0, func(renderer Renderer) {
+		view := camera.View()
+		screen := renderer.Bounds()
+		for _, projectile := range projectiles {
+			head := view.WorldToScreen(renderer, projectile.Coordinates())
+			if !screen.Contains(head) {
+				continue
+			}
+			switch {
+			case projectile.shot.Decoy:
+				renderer.Circle(head, 2*view.Zoom, 0, decoyColor)
+			case projectile.shot.Guidance != nil:
+				renderer.Polygon(arrowhead(head, projectile.Angle(), 4*view.Zoom), 0, missileColor)
+			default:
+				tail := head.Sub(projectile.Velocity().Unit().Scaled(boltLength * view.Zoom))
+				renderer.Line(tail, head, 2, boltColor)
+			}
+		}
+	})
+}
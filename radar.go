@@ -0,0 +1,150 @@
+package spacegame
+
+import (
+	"image/color"
+	"math"
+
+	"golang.org/x/image/colornames"
+
+	"github.com/faiface/pixel"
+)
+
+// How far the radar reaches at each zoom level, it never reaches further than the scanner
+var radarZoomLevels = []float64{2000, 8000, 32000, 128000}
+
+var (
+	radarBackground = color.RGBA{0, 24, 0, 192}
+	radarForeground = colornames.Darkgreen
+	radarHighlight  = colornames.Yellow
+)
+
+// The RadarWidget shows the scanner's contacts around the ship in a corner of the screen.
+// It can be toggled into a full-screen map of the solar system.
+type RadarWidget struct {
+	scanner *ShipScanner
+	size    float64
+}
+
+func NewRadarWidget(scanner *ShipScanner) *RadarWidget {
+	return &RadarWidget{
+		scanner: scanner,
+		size:    160,
+	}
+}
+
+func (rw *RadarWidget) Anchor() HUDAnchor {
+	if rw.scanner.systemMap {
+		return AnchorFill
+	}
+	return AnchorBottomRight
+}
+
+func (rw *RadarWidget) Size(renderer Renderer) pixel.Vec {
+	return pixel.V(rw.size, rw.size)
+}
+
+func (rw *RadarWidget) Render(renderer Renderer, bounds pixel.Rect) {
+	sc := rw.scanner
+	if sc.ship == nil {
+		return
+	}
+
+	center := bounds.Center()
+	radius := math.Min(bounds.W(), bounds.H()) / 2
+
+	// the radar is centered on the ship, the map on the system
+	origin, reach := sc.ship.Coordinates(), sc.RadarRange()
+	if sc.systemMap {
+		origin, reach = systemExtent(sc.celestials, sc.ship)
+	}
+	scale := radius / reach
+
+	project := func(at pixel.Vec) (pixel.Vec, bool) {
+		offset := at.Sub(origin)
+		if offset.Len() > reach {
+			return pixel.ZV, false
+		}
+		return center.Add(offset.Scaled(scale)), true
+	}
+
+	renderer.Circle(center, radius, 0, radarBackground)
+	renderer.Circle(center, radius, 1, radarForeground)
+
+	for _, celestial := range sc.celestials {
+		position, ok := project(celestial.Coordinates())
+		if !ok {
+			continue
+		}
+		size := math.Max(celestial.Radius()*scale, 3)
+		renderer.Circle(position, size, 0, factionColor(celestial, colornames.Steelblue))
+		if selected := sc.Celestial(); selected != nil && selected.Name() == celestial.Name() {
+			renderer.Circle(position, size+3, 1, radarHighlight)
+		}
+	}
+
+	for _, target := range sc.targets {
+		// contacts out of the scanner's range are not shown, even if they would fit
+		if target.Coordinates().Sub(sc.ship.Coordinates()).Len() > sc.Range {
+			continue
+		}
+		position, ok := project(target.Coordinates())
+		if !ok {
+			continue
+		}
+		if _, ok := target.(*Asteroid); ok {
+			renderer.Circle(position, 1, 0, colornames.Gray)
+		} else {
+			renderer.Circle(position, 2, 0, factionColor(target, colornames.Lightgreen))
+		}
+		if target == sc.Target() {
+			renderer.Circle(position, 5, 1, radarHighlight)
+		}
+	}
+
+	if position, ok := project(sc.ship.Coordinates()); ok {
+		renderer.Polygon(arrowhead(position, sc.ship.Angle(), 5), 0, colornames.White)
+	}
+
+	renderer.Text(formatDistance(reach), pixel.V(bounds.Min.X, bounds.Min.Y), HUDTextStyle)
+}
+
+// The radar range at the current zoom level
+func (sc *ShipScanner) RadarRange() float64 {
+	return math.Min(radarZoomLevels[sc.radarZoom], sc.Range)
+}
+
+// Cycle through the zoom levels that are within the scanner's range
+func (sc *ShipScanner) ZoomRadar() {
+	sc.radarZoom = (sc.radarZoom + 1) % len(radarZoomLevels)
+	if radarZoomLevels[sc.radarZoom] > sc.Range {
+		sc.radarZoom = 0
+	}
+}
+
+func (sc *ShipScanner) ToggleSystemMap() {
+	sc.systemMap = !sc.systemMap
+}
+
+// systemExtent finds the middle of a system and how far it reaches from there
+func systemExtent(celestials []Celestial, ship Entity) (pixel.Vec, float64) {
+	const minimumReach = 1000
+
+	extents := pixel.R(0, 0, 0, 0).Moved(ship.Coordinates())
+	for _, celestial := range celestials {
+		position := celestial.Coordinates()
+		extents = extents.Union(pixel.R(position.X, position.Y, position.X, position.Y))
+	}
+
+	center := extents.Center()
+	reach := extents.Max.Sub(center).Len() * 1.1
+
+	return center, math.Max(reach, minimumReach)
+}
+
+// Things that belong to a faction are shown in the faction's color
+func factionColor(thing interface{}, fallback color.Color) color.Color {
+	if member, ok := thing.(interface{ Faction() Faction }); ok && member.Faction() != nil {
+		return member.Faction().Color()
+	}
+	return fallback
+}
@@ -0,0 +1,166 @@
+package spacegame
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"sort"
+
+	"github.com/faiface/pixel"
+)
+
+// Render layers, from back to front
+type RenderLayer int
+
+const (
+	LayerBackground RenderLayer = iota // stars and dust
+	LayerCelestials
+	LayerShips
+	LayerProjectiles
+	LayerEffects
+	LayerHUD
+)
+
+// Entities that belong on a specific layer implement Layered.
+// Celestials are drawn on LayerCelestials and everything else on LayerShips by default.
+type Layered interface {
+	RenderLayer() RenderLayer
+}
+
+// Entities that draw more than their own sprite (or something else entirely) implement Submitter
+type Submitter interface {
+	Submit(queue *RenderQueue, camera Camera)
+}
+
+type renderCommand struct {
+	layer RenderLayer
+	depth float64
+	draw  func(Renderer)
+}
+
+// A RenderQueue collects draw commands and executes them sorted by layer and depth when it is updated.
+// Within a layer, lower depths are drawn first (further away), and equal depths are drawn in submission order.
+// The queue is itself a Renderer, so cameras can render to it: entities are drawn on their default layer at depth 0.
+type RenderQueue struct {
+	renderer Renderer
+	commands []renderCommand
+}
+
+func NewRenderQueue(renderer Renderer) *RenderQueue {
+	return &RenderQueue{
+		renderer: renderer,
+	}
+}
+
+// Submit a draw command, draw is called with the underlying renderer
+func (rq *RenderQueue) Submit(layer RenderLayer, depth float64, draw func(Renderer)) {
+	rq.commands = append(rq.commands, renderCommand{
+		layer: layer,
+		depth: depth,
+		draw:  draw,
+	})
+}
+
+func (rq *RenderQueue) SubmitEntity(layer RenderLayer, depth float64, entity Entity, position pixel.Vec, scale float64) {
+	rq.Submit(layer, depth, func(renderer Renderer) {
+		renderer.Render(entity, position, scale)
+	})
+}
+
+// Draw all submitted commands in order and empty the queue
+func (rq *RenderQueue) Flush() {
+	sort.SliceStable(rq.commands, func(i, j int) bool {
+		a, b := rq.commands[i], rq.commands[j]
+		if a.layer != b.layer {
+			return a.layer < b.layer
+		}
+		return a.depth < b.depth
+	})
+
+	for _, command := range rq.commands {
+		command.draw(rq.renderer)
+	}
+	rq.commands = rq.commands[:0]
+}
+
+func (rq *RenderQueue) Bounds() pixel.Rect {
+	return rq.renderer.Bounds()
+}
+
+func (rq *RenderQueue) Capture() (*image.RGBA, error) {
+	return rq.renderer.Capture()
+}
+
+func (rq *RenderQueue) Center() pixel.Vec {
+	return rq.renderer.Center()
+}
+
+// Shapes are drawn on the HUD layer, submit them yourself to draw them elsewhere
+func (rq *RenderQueue) Circle(center pixel.Vec, radius, thickness float64, color color.Color) {
+	rq.Submit(LayerHUD, 0, func(renderer Renderer) {
+		renderer.Circle(center, radius, thickness, color)
+	})
+}
+
+// Clear drops anything that is still queued and clears the underlying renderer
+func (rq *RenderQueue) Clear() {
+	rq.commands = rq.commands[:0]
+	rq.renderer.Clear()
+}
+
+// The clipped renderer draws right away when its command comes up on the HUD layer
+func (rq *RenderQueue) Clip(rect pixel.Rect, draw func(Renderer)) {
+	rq.Submit(LayerHUD, 0, func(renderer Renderer) {
+		renderer.Clip(rect, draw)
+	})
+}
+
+func (rq *RenderQueue) Line(from, to pixel.Vec, thickness float64, color color.Color) {
+	rq.Submit(LayerHUD, 0, func(renderer Renderer) {
+		renderer.Line(from, to, thickness, color)
+	})
+}
+
+func (rq *RenderQueue) MeasureText(txt string, style TextStyle) pixel.Vec {
+	return rq.renderer.MeasureText(txt, style)
+}
+
+func (rq *RenderQueue) Polygon(points []pixel.Vec, thickness float64, color color.Color) {
+	rq.Submit(LayerHUD, 0, func(renderer Renderer) {
+		renderer.Polygon(points, thickness, color)
+	})
+}
+
+func (rq *RenderQueue) Render(renderable Entity, position pixel.Vec, scale float64) {
+	rq.SubmitEntity(layerOf(renderable), 0, renderable, position, scale)
+}
+
+func (rq *RenderQueue) ResourceManager() ResourceManager {
+	return rq.renderer.ResourceManager()
+}
+
+// Text is drawn on the HUD layer
+func (rq *RenderQueue) Text(txt string, position pixel.Vec, style TextStyle) error {
+	rq.Submit(LayerHUD, 0, func(renderer Renderer) {
+		if err := renderer.Text(txt, position, style); err != nil {
+			log.Println("Could not render text:", err)
+		}
+	})
+	return nil
+}
+
+// Update flushes the queue and updates the underlying renderer
+func (rq *RenderQueue) Update() {
+	rq.Flush()
+	rq.renderer.Update()
+}
+
+func layerOf(entity Entity) RenderLayer {
+	switch e := entity.(type) {
+	case Layered:
+		return e.RenderLayer()
+	case Celestial:
+		return LayerCelestials
+	}
+	return LayerShips
+}
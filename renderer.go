@@ -2,11 +2,14 @@ package spacegame
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 
 	"golang.org/x/image/colornames"
-	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font"
 
 	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/faiface/pixel/text"
 )
@@ -14,78 +17,223 @@ import (
 // This may be a prototype for a "subwindow" interface...
 type Renderer interface {
 	Bounds() pixel.Rect
+	Capture() (*image.RGBA, error) // Copy of the last rendered frame
 	Center() pixel.Vec
+	Circle(center pixel.Vec, radius, thickness float64, color color.Color) // thickness 0 fills the circle
 	Clear()
-	Render(renderable Entity, position pixel.Vec)
+	Clip(rect pixel.Rect, draw func(Renderer)) // draw gets a renderer for rect, its origin is rect.Min and nothing is drawn outside it
+	Line(from, to pixel.Vec, thickness float64, color color.Color)
+	MeasureText(txt string, style TextStyle) pixel.Vec                // Size of the text when it is rendered with style
+	Polygon(points []pixel.Vec, thickness float64, color color.Color) // thickness 0 fills the polygon
+	Render(renderable Entity, position pixel.Vec, scale float64)      // scale 1 draws the sprite the size of the entity's bounds
 	ResourceManager() ResourceManager
-	Text(txt string, position pixel.Vec) error
+	Text(txt string, position pixel.Vec, style TextStyle) error // position is the baseline of the first line
 	Update()
 }
 
+// Entities without a sprite implement Drawer and draw themselves with shapes, instead of the sprite Render would draw
+type Drawer interface {
+	Draw(renderer Renderer, position pixel.Vec, scale float64)
+}
+
 type PixelWindowRenderer struct {
 	window          *pixelgl.Window
 	resourceManager ResourceManager
-	atlas           *text.Atlas
+	atlases         map[font.Face]*text.Atlas
+	imd             *imdraw.IMDraw
+
+	// Clipped renderers draw on a canvas, which is drawn onto the parent when they are done
+	canvas  *pixelgl.Canvas
+	clipped map[pixel.Rect]*PixelWindowRenderer // canvases are expensive, so they are kept for each rect
 }
 
 func NewPixelWindowRenderer(window *pixelgl.Window, resourceManager ResourceManager) *PixelWindowRenderer {
-	atlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
 	return &PixelWindowRenderer{
 		window:          window,
 		resourceManager: resourceManager,
-		atlas:           atlas,
+		atlases:         make(map[font.Face]*text.Atlas),
+		imd:             imdraw.New(nil),
+	}
+}
+
+// Everything is drawn on the window, unless this is a clipped renderer
+func (pwr *PixelWindowRenderer) target() pixel.Target {
+	if pwr.canvas != nil {
+		return pwr.canvas
 	}
+	return pwr.window
 }
 
 func (pwr *PixelWindowRenderer) Bounds() pixel.Rect {
+	if pwr.canvas != nil {
+		return pwr.canvas.Bounds()
+	}
 	return pwr.window.Bounds()
 }
 
+// Reads back the window's framebuffer
+func (pwr *PixelWindowRenderer) Capture() (*image.RGBA, error) {
+	canvas := pwr.canvas
+	if canvas == nil {
+		canvas = pwr.window.Canvas()
+	}
+	bounds := canvas.Bounds()
+	w, h := int(bounds.W()), int(bounds.H())
+
+	pixels := canvas.Pixels()
+	if len(pixels) != w*h*4 {
+		return nil, fmt.Errorf("expected %d bytes of pixels, got %d", w*h*4, len(pixels))
+	}
+
+	// the framebuffer's origin is the bottom left corner
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		row := pixels[(h-1-y)*w*4 : (h-y)*w*4]
+		copy(img.Pix[y*img.Stride:], row)
+	}
+	return img, nil
+}
+
 func (pwr *PixelWindowRenderer) Center() pixel.Vec {
-	return pwr.window.Bounds().Center()
+	return pwr.Bounds().Center()
+}
+
+func (pwr *PixelWindowRenderer) Circle(center pixel.Vec, radius, thickness float64, color color.Color) {
+	pwr.imd.Color = color
+	pwr.imd.Push(center)
+	pwr.imd.Circle(radius, thickness)
+	pwr.drawShape()
 }
 
 func (pwr *PixelWindowRenderer) Clear() {
+	if pwr.canvas != nil {
+		pwr.canvas.Clear(colornames.Black)
+		return
+	}
 	pwr.window.Clear(colornames.Black)
 }
 
-func (pwr *PixelWindowRenderer) Render(renderable Entity, position pixel.Vec) {
-	// return early if position is out of bounds
-	if pwr.window.Bounds().Intersect(renderable.Bounds().Moved(position)).Area() == 0 {
-		return
+// The clipped renderer draws on a canvas the size of rect, which is drawn in place when draw is done
+func (pwr *PixelWindowRenderer) Clip(rect pixel.Rect, draw func(Renderer)) {
+	rect = rect.Norm()
+	if pwr.clipped == nil {
+		pwr.clipped = make(map[pixel.Rect]*PixelWindowRenderer)
+	}
+	clipped, ok := pwr.clipped[rect]
+	if !ok {
+		clipped = &PixelWindowRenderer{
+			window:          pwr.window,
+			resourceManager: pwr.resourceManager,
+			atlases:         pwr.atlases,
+			imd:             imdraw.New(nil),
+			canvas:          pixelgl.NewCanvas(pixel.R(0, 0, rect.W(), rect.H())),
+		}
+		pwr.clipped[rect] = clipped
 	}
 
-	resource := pwr.resourceManager.Resource(renderable)
-	sprite := resource.sprite
+	clipped.canvas.Clear(color.Transparent)
+	draw(clipped)
+	// the canvas is drawn centered, like a sprite
+	clipped.canvas.Draw(pwr.target(), pixel.IM.Moved(rect.Center()))
+}
 
-	bounds := resource.Bounds()
-	unitScalerX, unitScalerY := 1/bounds.W(), 1/bounds.H()
-	rect := resource.Entity().Bounds()
+func (pwr *PixelWindowRenderer) Line(from, to pixel.Vec, thickness float64, color color.Color) {
+	pwr.imd.Color = color
+	pwr.imd.Push(from, to)
+	pwr.imd.Line(thickness)
+	pwr.drawShape()
+}
 
-	matrix := pixel.IM
-	matrix = matrix.ScaledXY(pixel.ZV, pixel.V(unitScalerX, unitScalerY))
-	matrix = matrix.ScaledXY(pixel.ZV, pixel.V(rect.W(), rect.H()))
-	matrix = matrix.Rotated(pixel.ZV, renderable.Angle())
-	matrix = matrix.Moved(position)
+func (pwr *PixelWindowRenderer) MeasureText(txt string, style TextStyle) pixel.Vec {
+	return measureText(textFace(pwr.resourceManager, style), txt, style)
+}
+
+func (pwr *PixelWindowRenderer) Polygon(points []pixel.Vec, thickness float64, color color.Color) {
+	pwr.imd.Color = color
+	pwr.imd.Push(points...)
+	pwr.imd.Polygon(thickness)
+	pwr.drawShape()
+}
 
-	sprite.Draw(pwr.window, matrix)
+// Shapes are drawn right away, so that they are layered correctly with sprites
+func (pwr *PixelWindowRenderer) drawShape() {
+	pwr.imd.Draw(pwr.target())
+	pwr.imd.Clear()
+}
+
+func (pwr *PixelWindowRenderer) Render(renderable Entity, position pixel.Vec, scale float64) {
+	// return early if position is out of bounds
+	if !visible(pwr.Bounds(), renderable, position, scale) {
+		return
+	}
+	if drawer, ok := renderable.(Drawer); ok {
+		drawer.Draw(pwr, position, scale)
+		return
+	}
+
+	resource := pwr.resourceManager.Resource(renderable)
+	resource.sprite.Draw(pwr.target(), spriteMatrix(resource, renderable, position, scale))
 }
 
 func (pwr *PixelWindowRenderer) ResourceManager() ResourceManager {
 	return pwr.resourceManager
 }
 
-func (pwr *PixelWindowRenderer) Text(s string, position pixel.Vec) error {
-	txt := text.New(position, pwr.atlas)
-	_, err := fmt.Fprintln(txt, s)
-	if err != nil {
-		return err
+func (pwr *PixelWindowRenderer) Text(s string, position pixel.Vec, style TextStyle) error {
+	face := textFace(pwr.resourceManager, style)
+	atlas := pwr.atlas(face)
+	for _, line := range layoutText(face, s, position, style) {
+		txt := text.New(line.dot, atlas)
+		if style.Color != nil {
+			txt.Color = style.Color
+		}
+		_, err := fmt.Fprint(txt, line.text)
+		if err != nil {
+			return err
+		}
+		txt.Draw(pwr.target(), pixel.IM)
 	}
+	return nil
+}
 
-	txt.Draw(pwr.window, pixel.IM)
-    return nil
+// Atlases are expensive to create, so there is one per face
+func (pwr *PixelWindowRenderer) atlas(face font.Face) *text.Atlas {
+	atlas, ok := pwr.atlases[face]
+	if !ok {
+		atlas = text.NewAtlas(face, text.ASCII)
+		pwr.atlases[face] = atlas
+	}
+	return atlas
 }
 
+// Clipped renderers are drawn onto their parent by Clip, only the window needs updating
 func (pwr *PixelWindowRenderer) Update() {
+	if pwr.canvas != nil {
+		return
+	}
 	pwr.window.Update()
 }
+
+// visible reports whether renderable, placed at position and scaled, overlaps bounds
+func visible(bounds pixel.Rect, renderable Entity, position pixel.Vec, scale float64) bool {
+	rect := renderable.Bounds()
+	rect = rect.Resized(rect.Center(), rect.Size().Scaled(scale))
+	return bounds.Intersect(rect.Moved(position)).Area() != 0
+}
+
+// spriteMatrix maps a resource's sprite onto the screen: the sprite is scaled
+// to the bounds of the resource's entity, rotated by the renderable's angle and
+// moved to position. Every Renderer should use this so that they all agree.
+func spriteMatrix(resource *Resource, renderable Entity, position pixel.Vec, scale float64) pixel.Matrix {
+	bounds := resource.Bounds()
+	unitScalerX, unitScalerY := 1/bounds.W(), 1/bounds.H()
+	rect := resource.Entity().Bounds()
+
+	matrix := pixel.IM
+	matrix = matrix.ScaledXY(pixel.ZV, pixel.V(unitScalerX, unitScalerY))
+	matrix = matrix.ScaledXY(pixel.ZV, pixel.V(rect.W(), rect.H()).Scaled(scale))
+	matrix = matrix.Rotated(pixel.ZV, renderable.Angle())
+	matrix = matrix.Moved(position)
+
+	return matrix
+}
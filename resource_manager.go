@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/faiface/pixel"
@@ -34,21 +35,34 @@ func (r Resource) Bounds() pixel.Rect {
 
 type ResourceManager interface {
 	CreateResource(renderable Entity, path string)
+	Emitter(name string) (*EmitterConfig, error)
 	Find(search string) []Resource
 	FindInCollection(collection string) []Resource
+	Font(name string, size float64) (font.Face, error)
 	ImportDefault() // TODO: Import(options GameOptions)
 	Resource(renderable Entity) *Resource
 }
 
+type fontKey struct {
+	name string
+	size float64
+}
+
 type StandardResourceManager struct {
 	basePath  string // for custom resource packs
 	resources map[string]Resource
+	fonts     map[string]*truetype.Font
+	faces     map[fontKey]font.Face
+	emitters  map[string]*EmitterConfig
 }
 
 func NewStandardResourceManager(baseResourcePath string) *StandardResourceManager {
 	return &StandardResourceManager{
 		basePath:  baseResourcePath,
 		resources: make(map[string]Resource),
+		fonts:     make(map[string]*truetype.Font),
+		faces:     make(map[fontKey]font.Face),
+		emitters:  make(map[string]*EmitterConfig),
 	}
 }
 
@@ -74,10 +88,9 @@ func (srm *StandardResourceManager) Find(search string) []Resource {
 		match := strings.Index(name, search)
 		if match != -1 {
 			matched = append(matched, srm.resources[i])
-		} else {
-			log.Printf("%s didn't match %s\n", resource.entity.Name(), search)
 		}
 	}
+	sortResources(matched)
 	return matched
 }
 func (srm *StandardResourceManager) FindInCollection(collection string) []Resource {
@@ -91,9 +104,39 @@ func (srm *StandardResourceManager) FindInCollection(collection string) []Resour
 
 	//	log.Println("Created this beautiful collection for", collection)
 	//	log.Println(matched)
+	sortResources(matched)
 	return matched
 }
 
+// Emitter returns the particle emitter with the name, emitters are named by their file name
+func (srm *StandardResourceManager) Emitter(name string) (*EmitterConfig, error) {
+	emitter, ok := srm.emitters[name]
+	if !ok {
+		return nil, fmt.Errorf("Emitter not found: %s", name)
+	}
+	return emitter, nil
+}
+
+// Font returns a face of a registered font at the specified size
+// Fonts are registered by their file name, without the extension
+func (srm *StandardResourceManager) Font(name string, size float64) (font.Face, error) {
+	key := fontKey{name, size}
+	if face, ok := srm.faces[key]; ok {
+		return face, nil
+	}
+
+	ttf, ok := srm.fonts[name]
+	if !ok {
+		return nil, fmt.Errorf("Font not found: %s", name)
+	}
+
+	face := truetype.NewFace(ttf, &truetype.Options{
+		Size: size,
+	})
+	srm.faces[key] = face
+	return face, nil
+}
+
 // Imports everything we can find
 func (srm *StandardResourceManager) ImportDefault() {
 	// TODO: "makeWalkHandler"
@@ -222,10 +265,103 @@ func (srm *StandardResourceManager) ImportDefault() {
 		return nil
 	}
 
+	// turret sprites are drawn at half their size, so they're as sharp as the ships they sit on
+	turretImporter := func(path string, info os.FileInfo, err error) error {
+		// fail on error
+		if err != nil {
+			return err
+		}
+
+		// skip directories
+		if info.IsDir() {
+			return nil
+		}
+
+		collection, filename := filepath.Split(path)
+
+		name := strings.Replace(filename, filepath.Ext(filename), "", 1)
+
+		pic, err := loadPicture(path)
+		if err != nil {
+			// TODO: error handler
+			panic(err)
+		}
+
+		entity := NewBaseEntity(name, pixel.R(0, 0, pic.Bounds().W()/2, pic.Bounds().H()/2))
+
+		resource := srm.createResource(pic, entity)
+		resource.collection = collection
+
+		srm.resources[name] = resource
+
+		return nil
+	}
+
+	fontImporter := func(path string, info os.FileInfo, err error) error {
+		// fail on error
+		if err != nil {
+			return err
+		}
+
+		// skip directories
+		if info.IsDir() {
+			return nil
+		}
+
+		// only import truetype fonts
+		if filepath.Ext(path) != ".ttf" {
+			return nil
+		}
+		_, filename := filepath.Split(path)
+
+		name := strings.Replace(filename, filepath.Ext(filename), "", 1)
+
+		ttf, err := loadTTF(path)
+		if err != nil {
+			return err
+		}
+
+		srm.fonts[name] = ttf
+
+		return nil
+	}
+
+	emitterImporter := func(path string, info os.FileInfo, err error) error {
+		// fail on error
+		if err != nil {
+			return err
+		}
+
+		// skip directories
+		if info.IsDir() {
+			return nil
+		}
+
+		// only import json files
+		if filepath.Ext(path) != ".json" {
+			return nil
+		}
+		_, filename := filepath.Split(path)
+
+		name := strings.Replace(filename, filepath.Ext(filename), "", 1)
+
+		emitter, err := LoadEmitterConfig(path)
+		if err != nil {
+			return err
+		}
+
+		srm.emitters[name] = emitter
+
+		return nil
+	}
+
 	shipPath := fmt.Sprintf("%s/entities/ships", srm.basePath)
 	systemPath := fmt.Sprintf("%s/universe/systems", srm.basePath)
 	starPath := fmt.Sprintf("%s/images/stars", srm.basePath)
 	dustPath := fmt.Sprintf("%s/images/dust", srm.basePath)
+	turretPath := fmt.Sprintf("%s/images/turrets", srm.basePath)
+	fontPath := fmt.Sprintf("%s/fonts", srm.basePath)
+	effectPath := fmt.Sprintf("%s/effects", srm.basePath)
 
 	var err error
 
@@ -252,6 +388,24 @@ func (srm *StandardResourceManager) ImportDefault() {
 	if err != nil {
 		panic(err)
 	}
+
+	// import turrets
+	err = filepath.Walk(turretPath, turretImporter)
+	if err != nil {
+		panic(err)
+	}
+
+	// import fonts
+	err = filepath.Walk(fontPath, fontImporter)
+	if err != nil {
+		panic(err)
+	}
+
+	// import particle emitters
+	err = filepath.Walk(effectPath, emitterImporter)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (srm *StandardResourceManager) Resource(renderable Entity) *Resource {
@@ -272,7 +426,14 @@ func (srm *StandardResourceManager) createResource(pic pixel.Picture, entity Ent
 	}
 }
 
-func loadTTF(path string, size float64) (font.Face, error) {
+// Resources are stored in a map, sort them by name so that searches are stable
+func sortResources(resources []Resource) {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].entity.Name() < resources[j].entity.Name()
+	})
+}
+
+func loadTTF(path string) (*truetype.Font, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -284,13 +445,5 @@ func loadTTF(path string, size float64) (font.Face, error) {
 		return nil, err
 	}
 
-	font, err := truetype.Parse(bytes)
-	if err != nil {
-		return nil, err
-	}
-
-	return truetype.NewFace(font, &truetype.Options{
-		Size:              size,
-		GlyphCacheEntries: 1,
-	}), nil
+	return truetype.Parse(bytes)
 }
@@ -0,0 +1,99 @@
+package spacegame
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// A ScaledRenderer draws at a virtual resolution on another renderer.
+// Everything is scaled so that the virtual resolution fits the screen, the shorter side decides the scale.
+// The virtual bounds stretch along the longer side to keep the screen's aspect ratio.
+type ScaledRenderer struct {
+	renderer   Renderer
+	resolution pixel.Vec
+}
+
+func NewScaledRenderer(renderer Renderer, resolution pixel.Vec) *ScaledRenderer {
+	return &ScaledRenderer{
+		renderer:   renderer,
+		resolution: resolution,
+	}
+}
+
+// How many screen pixels each virtual pixel takes
+func (sr *ScaledRenderer) Scale() float64 {
+	screen := sr.renderer.Bounds()
+	return math.Min(screen.W()/sr.resolution.X, screen.H()/sr.resolution.Y)
+}
+
+func (sr *ScaledRenderer) toScreen(v pixel.Vec) pixel.Vec {
+	return v.Scaled(sr.Scale()).Add(sr.renderer.Bounds().Min)
+}
+
+func (sr *ScaledRenderer) scaleStyle(style TextStyle) TextStyle {
+	style.Size *= sr.Scale()
+	style.WrapWidth *= sr.Scale()
+	return style
+}
+
+func (sr *ScaledRenderer) Bounds() pixel.Rect {
+	screen, scale := sr.renderer.Bounds(), sr.Scale()
+	return pixel.R(0, 0, screen.W()/scale, screen.H()/scale)
+}
+
+func (sr *ScaledRenderer) Capture() (*image.RGBA, error) {
+	return sr.renderer.Capture()
+}
+
+func (sr *ScaledRenderer) Center() pixel.Vec {
+	return sr.Bounds().Center()
+}
+
+func (sr *ScaledRenderer) Circle(center pixel.Vec, radius, thickness float64, color color.Color) {
+	sr.renderer.Circle(sr.toScreen(center), radius*sr.Scale(), thickness*sr.Scale(), color)
+}
+
+func (sr *ScaledRenderer) Clear() {
+	sr.renderer.Clear()
+}
+
+// The clipped renderer draws at the screen's resolution, it isn't scaled
+func (sr *ScaledRenderer) Clip(rect pixel.Rect, draw func(Renderer)) {
+	sr.renderer.Clip(pixel.Rect{Min: sr.toScreen(rect.Min), Max: sr.toScreen(rect.Max)}, draw)
+}
+
+func (sr *ScaledRenderer) Line(from, to pixel.Vec, thickness float64, color color.Color) {
+	sr.renderer.Line(sr.toScreen(from), sr.toScreen(to), thickness*sr.Scale(), color)
+}
+
+// The built-in font can't be scaled, text in it will look small
+func (sr *ScaledRenderer) MeasureText(txt string, style TextStyle) pixel.Vec {
+	return sr.renderer.MeasureText(txt, sr.scaleStyle(style)).Scaled(1 / sr.Scale())
+}
+
+func (sr *ScaledRenderer) Polygon(points []pixel.Vec, thickness float64, color color.Color) {
+	scaled := make([]pixel.Vec, len(points))
+	for i, point := range points {
+		scaled[i] = sr.toScreen(point)
+	}
+	sr.renderer.Polygon(scaled, thickness*sr.Scale(), color)
+}
+
+func (sr *ScaledRenderer) Render(renderable Entity, position pixel.Vec, scale float64) {
+	sr.renderer.Render(renderable, sr.toScreen(position), scale*sr.Scale())
+}
+
+func (sr *ScaledRenderer) ResourceManager() ResourceManager {
+	return sr.renderer.ResourceManager()
+}
+
+func (sr *ScaledRenderer) Text(txt string, position pixel.Vec, style TextStyle) error {
+	return sr.renderer.Text(txt, sr.toScreen(position), sr.scaleStyle(style))
+}
+
+func (sr *ScaledRenderer) Update() {
+	sr.renderer.Update()
+}
@@ -2,6 +2,8 @@ package spacegame
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math"
 	"sync"
 
 	"github.com/faiface/pixel"
@@ -9,101 +11,442 @@ import (
 
 type Scene interface {
 	Render()
+	Resize(bounds pixel.Rect)
 	tick(float64)
 }
 
+// Scenes the pilot can point at with the mouse implement Aimable
+type Aimable interface {
+	Aim(screen pixel.Vec)
+}
+
+// The resolution the HUD is designed for, when it is scaled to the screen
+var defaultVirtualResolution = pixel.V(1280, 720)
+
 // TODO: Extract Ship into Player...
 type SpaceScene struct {
-	renderer   Renderer
-	camera     Camera
-	system     *SolarSystem
-	playerShip *Ship
-	entities   []Entity
-	starscape  Background
+	renderer    Renderer
+	camera      *EffectCamera
+	system      *SolarSystem
+	playerShip  *Ship
+	entities    []Entity
+	starscape   Background
+	queue       *RenderQueue
+	hud         *HUD
+	markers     *MarkerRenderer
+	objectives  []Entity
+	particles   *ParticleSystem
+	projectiles *ProjectilePool
+	debug       *DebugOverlay
+	lag         float64 // seconds the physics are behind the scene
+	collisions  *CollisionSystem
+	colliders   []Collider
+	contacts    []Contact // found by the last physics step
+	seed        int64
+	gameOver    bool // the player's ship was destroyed
+
+	virtualResolution pixel.Vec // the HUD is scaled from this resolution to the screen, unless it is zero
 }
 
 type SceneInformation struct {
-	Entities   []Entity
-	Celestials []Celestial
+	Entities    []Entity
+	Celestials  []Celestial
+	Particles   *ParticleSystem
+	Projectiles *ProjectilePool // weapons fire into it
+	Contacts    []Contact       // the colliders that touched in the last physics step
+	Elapsed     float64         // seconds since the last update
+	Seed        int64           // for whatever the ships make up, a scene with the same seed plays out the same
 }
 
-func NewSpaceScene(system *SolarSystem, player *Player, renderer Renderer) *SpaceScene {
-	camera := NewChaseCamera(player.Ship())
+// seedFor mixes a seed with where something is, so everything seeded from the scene gets numbers of its own
+func seedFor(seed int64, where ...interface{}) int64 {
+	hash := fnv.New64a()
+	fmt.Fprint(hash, append([]interface{}{seed}, where...)...)
+	return int64(hash.Sum64())
+}
+
+// The camera shake, the particles and the ships' weapons come from seed, so offscreen renders can be reproduced
+func NewSpaceScene(system *SolarSystem, player *Player, renderer Renderer, seed int64) *SpaceScene {
+	camera := NewEffectCamera(NewChaseCamera(player.Ship()), seed)
 	return &SpaceScene{
-		camera:     camera,
-		system:     system,
-		playerShip: player.Ship(),
-		entities:   []Entity{player.Ship()},
-		renderer:   renderer,
-		starscape:  NewStarscape(renderer, camera, 0.2),
+		camera:      camera,
+		system:      system,
+		playerShip:  player.Ship(),
+		entities:    []Entity{player.Ship()},
+		renderer:    renderer,
+		starscape:   NewStarscape(renderer, camera, 0.2),
+		queue:       NewRenderQueue(renderer),
+		hud:         NewHUD(),
+		markers:     NewMarkerRenderer(player.Ship()),
+		particles:   NewParticleSystem(renderer.ResourceManager(), 1<<16, seed),
+		projectiles: NewProjectilePool(1 << 12),
+		debug:       NewDebugOverlay(),
+		collisions:  NewCollisionSystem(64),
+		seed:        seed,
 	}
 }
 
-// TODO: Render with camera
+// Everything is submitted to the render queue, which decides the draw order
 func (ss *SpaceScene) Render() {
-	//
-
 	// Render the background
-	ss.renderer.Clear()
+	ss.queue.Clear()
 
 	// Starscape
-	ss.starscape.Render()
-
-	// Directional arrow TODO
+	ss.starscape.Submit(ss.queue)
 
 	// Render any planets in this scene
 	for _, celestial := range ss.system.Celestials() {
-		ss.camera.Render(ss.renderer, celestial)
+		ss.submit(celestial)
+	}
+
+	// Ships, and anything else that is in the scene
+	for _, entity := range ss.entities {
+		ss.submit(entity)
 	}
 
-	// Asteroids (if any) TODO
+	// Whatever the weapons fired
+	ss.projectiles.Submit(ss.queue, ss.camera)
+
+	// Special effects, explosions
+	ss.particles.Submit(ss.queue, ss.camera)
+
+	// Reticles, or directional arrows for whatever is off-screen
+	ss.markers.Render(ss.queue, ss.camera, ss.Markers())
+
+	// Bounds, vectors and ranges, if the debug overlay is on
+	ss.debug.Render(ss.queue, ss.camera, SceneInformation{
+		Entities:   ss.entities,
+		Celestials: ss.system.Celestials(),
+		Particles:  ss.particles,
+		Contacts:   ss.contacts,
+	})
 
-	// Friendly players/NPCs TODO
+	// The ship "renders" the HUD, so it can respond to stimuli, until it's destroyed
+	var widgets []HUDWidget
+	if ss.gameOver {
+		widgets = []HUDWidget{NewTextWidget(AnchorBottomLeft, func() []string {
+			return []string{"Your ship was destroyed"}
+		})}
+	} else {
+		widgets = ss.playerShip.HUDWidgets()
+	}
+	widgets = append(widgets, ss.debug.Widgets()...)
+	ss.hud.Render(ss.ui(), widgets)
 
-	// Projectiles TODO
+	ss.queue.Update()
+}
+
+// The renderer for the HUD, scaled to the virtual resolution if there is one
+func (ss *SpaceScene) ui() Renderer {
+	if ss.virtualResolution == pixel.ZV {
+		return ss.queue
+	}
+	return NewScaledRenderer(ss.queue, ss.virtualResolution)
+}
 
-	// Neutral or enemy players/NPCs TODO
+// Scale the HUD from resolution to the screen, or draw it at the screen's resolution if it is zero
+func (ss *SpaceScene) SetVirtualResolution(resolution pixel.Vec) {
+	ss.virtualResolution = resolution
+}
 
-	// Render the player's ship last, in the middle
-	ss.camera.Render(ss.renderer, ss.playerShip)
+// The camera and HUD follow the screen by themselves, the starscape needs to be told
+func (ss *SpaceScene) Resize(bounds pixel.Rect) {
+	ss.starscape.Resize(bounds)
+}
 
-    // Special effects, explosions
+// Aim the player's turrets at the point in the world under screen
+func (ss *SpaceScene) Aim(screen pixel.Vec) {
+	if ss.gameOver {
+		return
+	}
+	ss.playerShip.Aim(ss.camera.ScreenToWorld(ss.renderer, screen))
+}
 
-	// Very basic HUD 
-    // Make it better TODO: The ship "renders" the HUD! And can therefore respond appropriately to stimuli -- needs some engineering
-    pos := ss.playerShip.Coordinates()
-	hudTxt := fmt.Sprintf("Position: %.0f, %.0f\nVelocity: %4.2f", pos.X, pos.Y, ss.playerShip.Velocity().Len())
-	ss.renderer.Text(hudTxt, pixel.V(30, 30))
+// Destroy removes an entity from the scene with an explosion
+func (ss *SpaceScene) Destroy(entity Entity) {
+	for i, e := range ss.entities {
+		if e == entity {
+			ss.entities = append(ss.entities[:i:i], ss.entities[i+1:]...)
+			break
+		}
+	}
 
-    // Get HUD elements from player's ship
+	ss.particles.Burst("explosion", entity.Coordinates(), entity.Velocity())
+	ss.particles.Burst("debris", entity.Coordinates(), entity.Velocity())
 
-    // Apply HUD elements' renderer methods
+	// the closer the explosion, the harder it shakes
+	distance := entity.Coordinates().Sub(ss.playerShip.Coordinates()).Len()
+	ss.camera.Shake(math.Max(0, 1-distance/1000))
+}
 
-	ss.renderer.Update()
+// Mark an entity as a mission objective
+func (ss *SpaceScene) AddObjective(entity Entity) {
+	ss.objectives = append(ss.objectives, entity)
+}
+
+// Markers for the player's scanner targets, hostiles and objectives
+func (ss *SpaceScene) Markers() []Marker {
+	if ss.gameOver {
+		return nil
+	}
+	var markers []Marker
+
+	if scanner, ok := ss.playerShip.systems["scanner"].(*ShipScanner); ok {
+		if target := scanner.Target(); target != nil {
+			markers = append(markers, Marker{target, MarkerTarget})
+		}
+		if celestial := scanner.Celestial(); celestial != nil {
+			markers = append(markers, Marker{celestial, MarkerTarget})
+		}
+	}
+
+	for _, entity := range ss.entities {
+		if entity != Entity(ss.playerShip) && hostile(ss.playerShip, entity) {
+			markers = append(markers, Marker{entity, MarkerHostile})
+		}
+	}
+
+	for _, objective := range ss.objectives {
+		markers = append(markers, Marker{objective, MarkerObjective})
+	}
+
+	return markers
+}
+
+func (ss *SpaceScene) submit(entity Entity) {
+	if submitter, ok := entity.(Submitter); ok {
+		submitter.Submit(ss.queue, ss.camera)
+		return
+	}
+	ss.camera.Render(ss.queue, entity)
 }
 
 func (ss *SpaceScene) tick(dt float64) {
-	// TODO: Beware dragons... hehe, learning opportunity
-	go ss.starscape.Displace(dt)
+	ss.debug.Frame(dt)
+
+	// the stars move with the camera, which follows the ship the physics move, so they take turns
+	ss.starscape.Displace(dt)
 
 	si := SceneInformation{
-		Celestials: ss.system.Celestials(),
-		Entities:   ss.entities,
+		Celestials:  ss.system.Celestials(),
+		Entities:    ss.entities,
+		Particles:   ss.particles,
+		Projectiles: ss.projectiles,
+		Contacts:    ss.contacts,
+		Elapsed:     dt,
+		Seed:        ss.seed,
 	}
 
 	var wg sync.WaitGroup
-	for _, entity := range ss.entities {
-		// all pilotable ships get updated
+	for i, entity := range ss.entities {
+		// all pilotable ships get updated, each with its own seed
 		if ship, ok := entity.(PilotableShip); ok {
+			info := si
+			info.Seed = seedFor(ss.seed, i)
 			wg.Add(1)
 			go func(s PilotableShip) {
 				defer wg.Done()
-				s.Update(si)
+				s.Update(info)
 			}(ship)
 		}
-
-		// Everything gets translated by its velocity
-		entity.Translate(entity.Velocity())
 	}
 	wg.Wait()
+
+	// Physics take fixed steps, left over time waits for the next tick
+	ss.lag = math.Min(ss.lag+dt, maxPhysicsLag)
+	for ss.lag >= physicsStep {
+		ss.step(physicsStep)
+		ss.lag -= physicsStep
+	}
+
+	ss.stream()
+	ss.particles.Update(dt)
+	ss.camera.Update(dt)
+}
+
+// Asteroids come and go with the player, only the ones nearby are in the scene
+func (ss *SpaceScene) stream() {
+	gone := make(map[Entity]bool)
+	for _, field := range ss.system.AsteroidFields() {
+		spawned, despawned := field.Stream(ss.playerShip.Coordinates())
+		for _, asteroid := range spawned {
+			ss.entities = append(ss.entities, asteroid)
+		}
+		for _, asteroid := range despawned {
+			gone[asteroid] = true
+		}
+	}
+	if len(gone) == 0 {
+		return
+	}
+	// the ships' systems still have the old slice, so it isn't filtered in place
+	entities := make([]Entity, 0, len(ss.entities))
+	for _, entity := range ss.entities {
+		if !gone[entity] {
+			entities = append(entities, entity)
+		}
+	}
+	ss.entities = entities
+}
+
+// Everything moves by its velocity, bodies by the forces on them as well
+func (ss *SpaceScene) step(dt float64) {
+	ss.system.SetTime(ss.system.Time() + dt)
+	celestials := ss.system.Celestials()
+	for _, entity := range ss.entities {
+		// gravity pulls for the whole step, the body integrates it with the engine's push
+		if attracted, ok := entity.(Attracted); ok {
+			attracted.ApplyForce(gravityAt(celestials, entity.Coordinates()).Scaled(attracted.Mass()))
+		}
+		if physical, ok := entity.(Physical); ok {
+			physical.Step(dt)
+			continue
+		}
+		entity.Translate(entity.Velocity().Scaled(dt))
+	}
+	ss.projectiles.Step(dt)
+	for _, projectile := range ss.projectiles.Projectiles() {
+		if projectile.Burning() {
+			// missiles point up at angle 0 too, the plume goes out the back
+			ss.particles.Emit("exhaust", projectile.Coordinates(), projectile.Velocity(), projectile.Angle()+math.Pi, dt)
+		}
+	}
+
+	ss.colliders = ss.colliders[:0]
+	for _, entity := range ss.entities {
+		if collider, ok := entity.(Collider); ok {
+			ss.colliders = append(ss.colliders, collider)
+		}
+	}
+	for _, celestial := range celestials {
+		if collider, ok := celestial.(Collider); ok {
+			ss.colliders = append(ss.colliders, collider)
+		}
+	}
+	for _, projectile := range ss.projectiles.Projectiles() {
+		ss.colliders = append(ss.colliders, projectile)
+	}
+	ss.contacts = ss.collisions.Detect(ss.colliders)
+	for _, contact := range ss.contacts {
+		// projectiles don't bounce, they hit
+		if projectile, ok := contact.A.(*Projectile); ok {
+			ss.hit(projectile, contact.B, contact.Point)
+			continue
+		}
+		if projectile, ok := contact.B.(*Projectile); ok {
+			ss.hit(projectile, contact.A, contact.Point)
+			continue
+		}
+		ss.impact(contact, resolve(contact))
+	}
+
+	// whatever was shot or crashed to pieces blows up
+	var destroyed []Entity
+	for _, entity := range ss.entities {
+		if destructible, ok := entity.(Destructible); ok && destructible.Destroyed() {
+			destroyed = append(destroyed, entity)
+		}
+	}
+	for _, entity := range destroyed {
+		ss.Destroy(entity)
+		if entity == Entity(ss.playerShip) {
+			ss.lose()
+		}
+	}
+}
+
+// lose ends the game when the player's ship is destroyed: it's out of the scene like any other wreck,
+// the camera stays where it blew up and the HUD says so, instead of showing the ship's systems
+func (ss *SpaceScene) lose() {
+	ss.gameOver = true
+	ss.playerShip.velocity, ss.playerShip.angularVelocity = pixel.ZV, 0
+}
+
+// A projectile damages what it hits and is gone, unless it's the ship that fired it.
+// Shooting a missile down takes out both of them.
+func (ss *SpaceScene) hit(projectile *Projectile, target Collider, point pixel.Vec) {
+	if Entity(target) == projectile.Owner() || projectile.dead {
+		return
+	}
+	if other, ok := target.(*Projectile); ok {
+		if other.dead || other.Owner() == projectile.Owner() {
+			return
+		}
+		ss.projectiles.Remove(other)
+		ss.particles.Burst("explosion", other.Coordinates(), other.Velocity())
+	}
+	ss.projectiles.Remove(projectile)
+	if damageable, ok := target.(Damageable); ok {
+		damageable.Damage(projectile.Damage())
+	}
+	effect := "impact"
+	if projectile.shot.Guidance != nil {
+		effect = "explosion"
+	}
+	ss.particles.Burst(effect, point, target.Velocity())
+	if target == Collider(ss.playerShip) {
+		ss.camera.Shake(math.Min(1, projectile.Damage()/50))
+	}
+}
+
+// What was hit takes damage, and the player feels it
+func (ss *SpaceScene) impact(contact Contact, energy float64) {
+	damage := energy * impactDamage
+	if damage < minImpactDamage {
+		return
+	}
+	for _, collider := range []Collider{contact.A, contact.B} {
+		if damageable, ok := collider.(Damageable); ok {
+			damageable.Damage(damage)
+		}
+	}
+
+	velocity := contact.A.Velocity().Add(contact.B.Velocity()).Scaled(0.5)
+	ss.particles.Burst("impact", contact.Point, velocity)
+	if contact.A == Collider(ss.playerShip) || contact.B == Collider(ss.playerShip) {
+		ss.camera.Shake(math.Min(1, damage/50))
+	}
+}
+
+// The scene handles the actions that concern the camera
+func (ss *SpaceScene) Process(a pilotAction) {
+	switch a.key {
+	case actionZoomIn:
+		ss.camera.ZoomBy(zoomStep)
+	case actionZoomOut:
+		ss.camera.ZoomBy(1 / zoomStep)
+	case actionDebug:
+		ss.debug.Toggle()
+	case actionFreeLook:
+		ss.camera.ToggleFreeLook()
+	case actionScaleUI:
+		if ss.virtualResolution == pixel.ZV {
+			ss.SetVirtualResolution(defaultVirtualResolution)
+		} else {
+			ss.SetVirtualResolution(pixel.ZV)
+		}
+	case actionPanUp:
+		ss.camera.Pan(pixel.V(0, 1), a.dt)
+	case actionPanDown:
+		ss.camera.Pan(pixel.V(0, -1), a.dt)
+	case actionPanLeft:
+		ss.camera.Pan(pixel.V(-1, 0), a.dt)
+	case actionPanRight:
+		ss.camera.Pan(pixel.V(1, 0), a.dt)
+	}
+}
+
+// Arrive sweeps the camera from an overview of the system down to the player's ship
+func (ss *SpaceScene) Arrive() {
+	center, reach := systemExtent(ss.system.Celestials(), ss.playerShip)
+	bounds := ss.renderer.Bounds()
+	overview := math.Min(bounds.W(), bounds.H()) / 2 / reach
+
+	ss.camera.Update(0) // settle the camera on the ship first
+	ship := ss.camera.View()
+	ss.camera.Push(NewCinematic(4,
+		CameraView{center, overview},
+		CameraView{ship.Position.Add(center.Sub(ship.Position).Scaled(0.1)), ship.Zoom / 2},
+		ship,
+	))
 }
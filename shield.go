@@ -0,0 +1,52 @@
+package spacegame
+
+import "math"
+
+// The shield soaks up damage before it gets to the armor and the hull.
+// It recharges by itself, but only once the ship hasn't been hit for a while.
+// The ship shows it on the HUD, with the armor and the hull.
+type ShipShield struct {
+	Capacity float64 // damage the shield takes when it's full
+	Recharge float64 // per second
+	Delay    float64 // seconds after a hit before the shield starts recharging
+	Mass     float64 `json:",omitempty"` // tonnes
+	ship     *Ship
+	charge   float64
+	quiet    float64 // seconds since the last hit
+}
+
+func (sh ShipShield) Name() string {
+	return "shield"
+}
+
+func (sh *ShipShield) Activate(command pilotAction) {}
+
+func (sh *ShipShield) Install(ship *Ship) {
+	sh.ship = ship
+	sh.charge = sh.Capacity
+	sh.quiet = sh.Delay
+}
+
+func (sh *ShipShield) weight() float64 {
+	return sh.Mass
+}
+
+func (sh *ShipShield) Update(info SceneInformation) {
+	sh.quiet += info.Elapsed
+	if sh.quiet >= sh.Delay {
+		sh.charge = math.Min(sh.Capacity, sh.charge+sh.Recharge*info.Elapsed)
+	}
+}
+
+// absorb takes what it can of amount, and returns the damage that gets through
+func (sh *ShipShield) absorb(amount float64) float64 {
+	sh.quiet = 0
+	absorbed := math.Min(sh.charge, amount)
+	sh.charge -= absorbed
+	return amount - absorbed
+}
+
+// How much damage the shield can still take, and could when it was full
+func (sh *ShipShield) Charge() (charge, capacity float64) {
+	return sh.charge, sh.Capacity
+}
@@ -2,8 +2,11 @@ package spacegame
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"os"
+	"sort"
 
 	"github.com/faiface/pixel"
 )
@@ -16,44 +19,64 @@ type PilotableShip interface {
 	Update(info SceneInformation)
 }
 
+// Ships that don't say how much their hull weighs, in tonnes, or how much it takes
+const (
+	defaultHullMass = 20
+	defaultHull     = 100
+)
+
 type Ship struct {
-	name        string
-	angle       float64 // Radians
-	coordinates pixel.Vec
-	velocity    pixel.Vec
-	bounds      pixel.Rect
-	systems     map[string]ShipSystem
+	body
+	name     string
+	hullMass float64 // the ship without its systems
+	hull     float64 // how much more damage the ship can take
+	maxHull  float64
+	armor    float64 // percent of the damage that gets through the shields that the armor stops
+	bounds   pixel.Rect
+	systems  map[string]ShipSystem
+	faction  Faction
 }
 
 type SerializableShip struct {
 	Name    string
 	Length  float64
 	Width   float64
+	Mass    float64 `json:",omitempty"` // of the hull, in tonnes
+	Hull    float64 `json:",omitempty"` // how much damage the ship can take
+	Armor   float64 `json:",omitempty"` // percent of the damage it takes that the armor stops
 	Systems ShipSystems
 }
 
+// Systems that weigh something add to the ship's mass
+type weighted interface {
+	weight() float64
+}
+
 // TODO: Put in some systems ?
 func DefaultShipConfig(name string) SerializableShip {
 	return SerializableShip{
 		Name:    name,
 		Length:  32,
 		Width:   32,
+		Mass:    defaultHullMass,
+		Hull:    defaultHull,
 		Systems: DefaultShipSystems(),
 	}
 }
 
 func NewShip(name string) *Ship {
 	ship := &Ship{
-		name:        name,
-		angle:       0.0,
-		coordinates: pixel.V(0, 0),
-		velocity:    pixel.V(0, 0),
-		bounds:      pixel.R(0, 0, 32, 32),
-		systems:     DefaultShipSystems(),
+		name:     name,
+		hullMass: defaultHullMass,
+		hull:     defaultHull,
+		maxHull:  defaultHull,
+		bounds:   pixel.R(0, 0, 32, 32),
+		systems:  DefaultShipSystems(),
 	}
 	for _, system := range ship.systems {
 		system.Install(ship)
 	}
+	ship.updateMass()
 	return ship
 }
 
@@ -75,16 +98,25 @@ func LoadShip(path string) (*Ship, error) {
 
 func (config SerializableShip) load() *Ship {
 	ship := &Ship{
-		name:        config.Name,
-		angle:       0.0,
-		coordinates: pixel.ZV,
-		bounds:      pixel.R(0, 0, config.Width, config.Length),
-		systems:     config.Systems,
+		name:     config.Name,
+		hullMass: config.Mass,
+		maxHull:  config.Hull,
+		armor:    math.Max(0, math.Min(100, config.Armor)),
+		bounds:   pixel.R(0, 0, config.Width, config.Length),
+		systems:  config.Systems,
+	}
+	if ship.hullMass <= 0 {
+		ship.hullMass = defaultHullMass
 	}
+	if ship.maxHull <= 0 {
+		ship.maxHull = defaultHull
+	}
+	ship.hull = ship.maxHull
 
 	for _, sys := range ship.systems {
 		sys.Install(ship)
 	}
+	ship.updateMass()
 	return ship
 }
 
@@ -117,6 +149,9 @@ func (s *Ship) Serialize() SerializableShip {
 		Name:    s.name,
 		Length:  s.bounds.Norm().H(),
 		Width:   s.bounds.Norm().W(),
+		Mass:    s.hullMass,
+		Hull:    s.maxHull,
+		Armor:   s.armor,
 		Systems: s.systems,
 	}
 
@@ -143,10 +178,102 @@ func (s *Ship) Velocity() pixel.Vec {
 	return s.velocity
 }
 
+// The faction the ship belongs to, or nil
+func (s *Ship) Faction() Faction {
+	return s.faction
+}
+
+func (s *Ship) SetFaction(faction Faction) {
+	s.faction = faction
+}
+
 func (s *Ship) Translate(by pixel.Vec) {
 	s.coordinates = s.coordinates.Add(by)
 }
 
+// Step lets the engine push for its part of the step, then moves the ship by all the forces on it
+func (s *Ship) Step(dt float64) {
+	if engine, ok := s.systems["engine"].(*ShipEngine); ok {
+		engine.step(dt)
+	}
+	s.body.Step(dt)
+}
+
+func (s *Ship) Shape() Shape {
+	return entityBox(s)
+}
+
+func (s *Ship) CollisionLayer() CollisionLayer {
+	return CollisionShips
+}
+
+func (s *Ship) CollisionMask() CollisionLayer {
+	return CollisionAll
+}
+
+// Damage is soaked up by the shield first, the armor stops some of what gets through and the hull takes the rest.
+// The hull never goes below zero, at zero the ship is destroyed.
+func (s *Ship) Damage(amount float64) {
+	if shield, ok := s.systems["shield"].(*ShipShield); ok {
+		amount = shield.absorb(amount)
+	}
+	amount *= 1 - s.armor/100
+	s.hull = math.Max(0, s.hull-amount)
+}
+
+// How much damage the ship can still take, and could when it was new
+func (s *Ship) Hull() (hull, max float64) {
+	return s.hull, s.maxHull
+}
+
+// How much damage the shield can still take, and could when it was full. Zero without a shield.
+func (s *Ship) Shields() (shields, max float64) {
+	if shield, ok := s.systems["shield"].(*ShipShield); ok {
+		return shield.Charge()
+	}
+	return 0, 0
+}
+
+// The percent of the damage that gets through the shields that the armor stops
+func (s *Ship) Armor() float64 {
+	return s.armor
+}
+
+// A ship without any hull left is destroyed, the scene takes it out
+func (s *Ship) Destroyed() bool {
+	return s.hull <= 0
+}
+
+// condition describes the ship's shields, armor and hull, for the HUD and the target panel
+func (s *Ship) condition() []string {
+	var lines []string
+	if shields, max := s.Shields(); max > 0 {
+		lines = append(lines, fmt.Sprintf("Shields:  %s %4.0f", gauge(shields, max, 10), shields))
+	}
+	if s.armor > 0 {
+		lines = append(lines, fmt.Sprintf("Armor:    %.0f%%", s.armor))
+	}
+	return append(lines, fmt.Sprintf("Hull:     %s %4.0f", gauge(s.hull, s.maxHull, 10), s.hull))
+}
+
+// The mass of the hull and everything installed on it, in tonnes
+func (s *Ship) Mass() float64 {
+	return s.mass
+}
+
+// The hull and its systems weigh something, call this when the systems change
+// TODO: Cargo
+func (s *Ship) updateMass() {
+	mass := s.hullMass
+	for _, sys := range s.systems {
+		if w, ok := sys.(weighted); ok {
+			mass += w.weight()
+		}
+	}
+	s.mass = mass
+	s.inertia = boxInertia(mass, s.bounds.Size())
+}
+
 /// TODO: Systems:
 func (s *Ship) ActivateSystem(system string, command pilotAction) {
 	// TODO:
@@ -163,6 +290,10 @@ func (s *Ship) ActivateSystem(system string, command pilotAction) {
 
 // TODO: Refactor
 func (s *Ship) Process(a pilotAction) {
+	// nobody is flying a wreck
+	if s.Destroyed() {
+		return
+	}
 	switch a.key {
 	case actionAccel, actionReverse, actionTurnLeft, actionTurnRight:
 		s.ActivateSystem("engine", a)
@@ -182,7 +313,7 @@ func (s *Ship) Process(a pilotAction) {
 			target.Land(s) // TODO: Maybe return something that can be shown to the pilot
 
 		}
-	case actionTargetNext, actionTargetPrev, actionClearTarget:
+	case actionTargetNext, actionTargetPrev, actionClearTarget, actionRadarZoom, actionSystemMap:
 		s.ActivateSystem("scanner", a)
 
 	case actionAlign:
@@ -201,42 +332,135 @@ func (s *Ship) Process(a pilotAction) {
 			return
 		}
 		target = scanner.Target()
-		log.Println("TARGET", target)
 		// no target, face celestial
 		if target == nil {
 			target = scanner.Celestial()
-			log.Println("TARGET", target, "target was nil")
 		}
 		if target == nil {
-			log.Println("NO TARGET")
 			return // nothing to align to // TODO: Maybe align to sun/origin?
 		}
-		log.Println("TARGET", target)
 		engine.Align(target, a.dt)
+
+	case actionApproach, actionIntercept, actionMatchVelocity, actionHoldPosition:
+		engine, ok := s.systems["engine"].(*ShipEngine)
+		if !ok {
+			return
+		}
+		var target Entity
+		if scanner, ok := s.systems["scanner"].(*ShipScanner); ok {
+			target = scanner.Target()
+			if a.key == actionApproach && scanner.Celestial() != nil {
+				target = scanner.Celestial()
+			}
+		}
+		mode := map[string]AutopilotMode{
+			actionApproach:      AutopilotApproach,
+			actionIntercept:     AutopilotIntercept,
+			actionMatchVelocity: AutopilotMatch,
+			actionHoldPosition:  AutopilotHold,
+		}[a.key]
+		// the same key again turns the autopilot off
+		if engine.Autopilot().Mode() == mode {
+			engine.Autopilot().Disengage()
+			return
+		}
+		engine.Autopilot().Engage(mode, target)
+
+	case actionFlightAssist:
+		if engine, ok := s.systems["engine"].(*ShipEngine); ok {
+			engine.CycleAssist()
+		}
+
+	case actionFire:
+		// every weapon on the ship fires together, turrets too
+		for _, sys := range s.systems {
+			switch sys.(type) {
+			case *ShipWeapon, *ShipTurret:
+				sys.Activate(a)
+			}
+		}
+
+	case actionTurretMode:
+		for _, turret := range s.turrets() {
+			turret.Activate(a)
+		}
+
+	case actionLaunch, actionDecoy:
+		s.ActivateSystem("launcher", a)
 	}
 }
 
-// TODO: Maybe supply selfIndex with Update method for optimization
+// The ship's turrets, ordered by system name
+func (s *Ship) turrets() []*ShipTurret {
+	var names []string
+	for name, sys := range s.systems {
+		if _, ok := sys.(*ShipTurret); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	turrets := make([]*ShipTurret, len(names))
+	for i, name := range names {
+		turrets[i] = s.systems[name].(*ShipTurret)
+	}
+	return turrets
+}
+
+// Aim the turrets that follow the mouse at a point in the world
+func (s *Ship) Aim(point pixel.Vec) {
+	for _, turret := range s.turrets() {
+		turret.Aim(point)
+	}
+}
+
+// Ships draw their turrets on top of the hull
+func (s *Ship) Submit(queue *RenderQueue, camera Camera) {
+	camera.Render(queue, s)
+	for _, turret := range s.turrets() {
+		view := turretView{turret}
+		queue.SubmitEntity(LayerShips, 0, view, camera.WorldToScreen(queue, view.Coordinates()), camera.Zoom())
+	}
+}
+
+// Collects the HUD widgets of all installed systems, ordered by system name, after the ship's own condition
+func (s *Ship) HUDWidgets() []HUDWidget {
+	var names []string
+	for name := range s.systems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	widgets := []HUDWidget{NewTextWidget(AnchorBottomLeft, s.condition)}
+	for _, name := range names {
+		if provider, ok := s.systems[name].(HUDProvider); ok {
+			widgets = append(widgets, provider.Widgets()...)
+		}
+	}
+	return widgets
+}
+
 func (s *Ship) Update(info SceneInformation) {
 	// Create a special SceneInformation that doesn't include "this ship"
-	entities := info.Entities
-	var selfIndex int = -1
-	var elen int = len(entities)
-	for i, _ := range entities {
-		if entities[i] == s {
-			selfIndex = i
-			break
+	// The scene's entities are shared by every ship that is updating, so copy them rather than modifying them in place
+	entities := make([]Entity, 0, len(info.Entities))
+	for _, entity := range info.Entities {
+		if entity != Entity(s) {
+			entities = append(entities, entity)
 		}
 	}
-	if selfIndex >= 0 {
-		entities[selfIndex] = entities[elen-1] // place self at the end
-		entities = entities[:elen-1]           // chop off the end
-	}
 	si := SceneInformation{
-		Celestials: info.Celestials,
-		Entities:   entities,
+		Celestials:  info.Celestials,
+		Entities:    entities,
+		Particles:   info.Particles,
+		Projectiles: info.Projectiles,
+		Contacts:    info.Contacts,
+		Elapsed:     info.Elapsed,
+		Seed:        info.Seed,
 	}
-	for _, sys := range s.systems {
+	for name, sys := range s.systems {
+		// the ship's systems each get their own seed too, so two guns don't spread their shots the same way
+		si.Seed = seedFor(info.Seed, name)
 		sys.Update(si)
 	}
 }
@@ -2,7 +2,7 @@ package spacegame
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"math"
 
 	"github.com/faiface/pixel"
@@ -57,6 +57,14 @@ func (s ShipSystems) UnmarshalJSON(buf []byte) error {
 			sys = &ShipEngine{}
 		case "scanner":
 			sys = &ShipScanner{}
+		case "weapon":
+			sys = &ShipWeapon{}
+		case "launcher":
+			sys = &ShipLauncher{}
+		case "turret":
+			sys = &ShipTurret{}
+		case "shield":
+			sys = &ShipShield{}
 		}
 		err := json.Unmarshal(rawsys.System, sys)
 		if err != nil {
@@ -67,18 +75,40 @@ func (s ShipSystems) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
+// The engine pushes the ship with its thrust and turns it with its maneuvering thrusters,
+// so how a ship handles depends on how heavy it is.
+// The pilot and the autopilot only queue up commands, the physics step applies them to the ship.
 type ShipEngine struct {
-	Acceleration float64
-	MaxVel       float64
-	TurnSpeed    float64
-	ship         *Ship
+	Thrust    float64 // tonne pixels/s², divided by the ship's mass this is its acceleration
+	Torque    float64 // of the maneuvering thrusters
+	MaxVel    float64 // pixels per second, the engine won't push the ship any faster
+	TurnSpeed float64 // radians per second, the thrusters won't spin the ship any faster
+	Mass      float64 `json:",omitempty"` // tonnes
+	Exhaust   string  `json:",omitempty"` // particle emitter for the thrust plume, "exhaust" by default
+	ship      *Ship
+	autopilot *Autopilot
+	assist    FlightAssist
+	thrust    float64 // seconds of thrust since the last update
+	turned    bool    // the thrusters were used since the last update
+	burn      float64 // seconds of thrust the physics steps haven't pushed the ship with yet
+	spinRate  float64 // the thrusters spin the ship toward this rate
+	spinTime  float64 // for this many more seconds of physics steps
 }
 
 func (se ShipEngine) Name() string {
 	return "engine"
 }
-func (se ShipEngine) Activate(command pilotAction) {
-	log.Println("engine activate", command)
+
+// Flying the ship by hand takes over from the autopilot
+func (se *ShipEngine) Activate(command pilotAction) {
+	switch command.key {
+	case actionAccel, actionTurnLeft, actionTurnRight, actionReverse:
+		se.autopilot.Disengage()
+	}
+	se.steer(command)
+}
+
+func (se *ShipEngine) steer(command pilotAction) {
 	switch command.key {
 	case actionAccel:
 		se.Accelerate(command.dt)
@@ -93,64 +123,158 @@ func (se ShipEngine) Activate(command pilotAction) {
 
 func (se *ShipEngine) Install(ship *Ship) {
 	se.ship = ship
-	log.Println("engine installed on ship", ship.name)
+	se.autopilot = NewAutopilot(se)
+}
+
+func (se *ShipEngine) Autopilot() *Autopilot {
+	return se.autopilot
+}
+
+func (se *ShipEngine) weight() float64 {
+	return se.Mass
+}
+
+// The ship's acceleration at full thrust, in pixels/s²
+func (se *ShipEngine) Acceleration() float64 {
+	return se.Thrust / se.ship.Mass()
+}
+
+// How fast the thrusters can change the ship's spin, in radians/s²
+func (se *ShipEngine) AngularAcceleration() float64 {
+	return se.Torque / se.ship.inertia
 }
 
+// The engine leaves a plume behind the ship while it is thrusting.
+// When nobody is turning the ship, the thrusters stop it from spinning.
 func (se *ShipEngine) Update(info SceneInformation) {
-	// No action necessary
+	if se.autopilot.lost(info) {
+		se.autopilot.Disengage()
+	}
+	for _, command := range se.autopilot.Commands(info.Elapsed) {
+		se.steer(command)
+	}
+	se.fullStop(info.Elapsed)
+
+	if !se.turned {
+		se.spin(0, info.Elapsed)
+	}
+	se.turned = false
+
+	thrust := se.thrust
+	se.thrust = 0
+	if thrust == 0 || info.Particles == nil {
+		return
+	}
+
+	exhaust := se.Exhaust
+	if exhaust == "" {
+		exhaust = "exhaust"
+	}
+	// the nozzle is at the back of the ship, ships point up at angle 0
+	back := pixel.V(0, -se.ship.Bounds().H()/2).Rotated(se.ship.angle)
+	info.Particles.Emit(exhaust, se.ship.Coordinates().Add(back), se.ship.Velocity(), se.ship.angle+math.Pi, thrust)
+}
+
+// The engine shows a speed gauge and the ship's heading
+func (se *ShipEngine) Widgets() []HUDWidget {
+	speed := NewTextWidget(AnchorBottomLeft, func() []string {
+		speed := se.ship.Velocity().Len()
+		return []string{
+			fmt.Sprintf("Speed:    %s %4.2f", gauge(speed, se.MaxVel, 10), speed),
+		}
+	})
+	navigation := NewTextWidget(AnchorBottomLeft, func() []string {
+		pos := se.ship.Coordinates()
+		lines := []string{
+			fmt.Sprintf("Position: %.0f, %.0f", pos.X, pos.Y),
+			fmt.Sprintf("Heading:  %03.0f", heading(se.ship.Angle())),
+			fmt.Sprintf("Assist:   %s", se.assist),
+		}
+		if se.autopilot.Mode() != AutopilotOff {
+			lines = append(lines, fmt.Sprintf("Autopilot: %s", se.autopilot))
+		}
+		return lines
+	})
+	return []HUDWidget{speed, navigation}
 }
 
 func (se *ShipEngine) Align(target Entity, dt float64) {
 	if target == nil {
 		return
 	}
-	location := se.ship.Coordinates()
-	destination := target.Coordinates()
-	log.Printf("Want to align to %s at %v from %v", target.Name(), target.Coordinates(), location)
-	direction := location.Sub(destination)
-	targetAngle := math.Atan2(direction.Y, direction.X)
-	se.align(targetAngle, dt)
+	direction := target.Coordinates().Sub(se.ship.Coordinates())
+	se.align(direction.Angle(), dt)
 }
 
+// align turns the ship to face the direction angle
 func (se *ShipEngine) align(angle float64, dt float64) {
 	// For now: Close enough, don't align more accurately
 	const deadZone = 0.05
 	// rotate so zero is upwards
-	angle = angle + math.Pi/2
-	log.Println("trying to align towards", angle, "; current:", se.ship.Angle())
+	angle = angle - math.Pi/2
 	// should be easy, make the ship's angle go towards param angle
 	// get difference
 	deltaAngle := normalizeAngle(se.ship.Angle() - angle)
-	// TODO: Make better engines align better
-	if deltaAngle < -deadZone {
-		se.Turn(dt)
-	} else if deltaAngle > deadZone {
-		se.Turn(-dt)
+
+	// spin toward the angle, but no faster than the thrusters can stop the ship on it
+	rate := 0.0
+	if math.Abs(deltaAngle) > deadZone {
+		rate = math.Min(se.TurnSpeed, math.Sqrt(2*se.AngularAcceleration()*math.Abs(deltaAngle)))
+		rate = math.Copysign(rate, -deltaAngle)
 	}
+	se.spin(rate, dt)
+	se.turned = true
 }
 
+// reverse turns the ship retrograde, against its velocity, so thrusting slows it down
 func (se *ShipEngine) reverse(dt float64) {
-	// naive reverse, turn around or do nothing
+	if se.ship.velocity == pixel.ZV {
+		return
+	}
+	se.align(se.ship.velocity.Angle()+math.Pi, dt)
+}
 
-	// ship's angle should go towards the inverse of its velocity
-	targetAngle := normalizeAngle(se.ship.Velocity().Angle() + 2*math.Pi)
-	se.align(targetAngle, dt)
+// Turn the ship for dt seconds, counterclockwise when dt is positive
+func (se *ShipEngine) Turn(dt float64) {
+	se.spin(math.Copysign(se.TurnSpeed, dt), math.Abs(dt))
+	se.turned = true
 }
 
-func (se ShipEngine) Turn(dt float64) {
-	angle := se.ship.angle + se.TurnSpeed*dt
+// spin fires the thrusters for dt seconds to bring the ship's spin toward rate
+func (se *ShipEngine) spin(rate, dt float64) {
+	se.spinRate = rate
+	se.spinTime += dt
+}
 
-	se.ship.angle = normalizeAngle(angle)
+// Accelerate fires the engine for dt seconds, the next physics steps push the ship
+func (se *ShipEngine) Accelerate(dt float64) {
+	se.thrust += dt
+	se.burn += dt
 }
 
-func (se ShipEngine) Accelerate(dt float64) {
-	thrust := se.Acceleration * dt
-	accelVec := pixel.V(0, thrust).Rotated(se.ship.angle)
-	vel := se.ship.velocity.Add(accelVec)
-	if vel.Len() > se.MaxVel {
-		vel = vel.Unit().Scaled(se.MaxVel)
+// step turns what the engine was told to do into forces on the ship, for its part of a physics step of dt
+func (se *ShipEngine) step(dt float64) {
+	if burn := math.Min(se.burn, dt); burn > 0 {
+		se.burn -= burn
+		velocity := se.ship.velocity
+		pushed := velocity.Add(pixel.V(0, se.Thrust*burn/se.ship.mass).Rotated(se.ship.angle))
+		if se.assist == AssistDrift {
+			pushed = pushed.Add(se.counterDrift(pushed, burn))
+		}
+		// the engine doesn't push past its top speed, but it doesn't brake either
+		limit := math.Max(velocity.Len(), se.MaxVel)
+		if pushed.Len() > limit {
+			pushed = pushed.Unit().Scaled(limit)
+		}
+		se.ship.ApplyForce(pushed.Sub(velocity).Scaled(se.ship.mass / dt))
+	}
+
+	if spin := math.Min(se.spinTime, dt); spin > 0 {
+		se.spinTime -= spin
+		torque := (se.spinRate - se.ship.angularVelocity) * se.ship.inertia / dt
+		most := se.Torque * spin / dt
+		se.ship.ApplyTorque(math.Max(-most, math.Min(most, torque)))
 	}
-	se.ship.velocity = vel
 }
 
 // TODO: Interface!!
@@ -159,17 +283,21 @@ func (se ShipEngine) Accelerate(dt float64) {
 type ShipScanner struct {
 	Accuracy       float64
 	Range          float64
+	Mass           float64 `json:",omitempty"` // tonnes
 	targets        []Entity
 	celestials     []Celestial
 	selectedTarget Entity
 	selectedCelest Celestial
+	ship           *Ship
+	radarZoom      int  // index into radarZoomLevels
+	systemMap      bool // show the system map instead of the radar
+	targetView     *TargetView
 }
 
 func (sc ShipScanner) Name() string {
 	return "scanner"
 }
 func (sc *ShipScanner) Activate(command pilotAction) {
-	log.Println("scanner activate", command)
 	switch command.key {
 	case actionTargetPrev:
 		sc.PrevTarget()
@@ -182,16 +310,75 @@ func (sc *ShipScanner) Activate(command pilotAction) {
 	case actionLand:
 		// The ship wants to land. A good scanner would target the nearest celestial...
 		sc.NextCelestial()
+
+	case actionRadarZoom:
+		sc.ZoomRadar()
+
+	case actionSystemMap:
+		sc.ToggleSystemMap()
 	}
 }
 
-func (sc ShipScanner) Install(ship *Ship) {
-	log.Println("scanner installed on ship", ship.name)
+func (sc *ShipScanner) Install(ship *Ship) {
+	sc.ship = ship
+}
+
+func (sc *ShipScanner) weight() float64 {
+	return sc.Mass
 }
 
 func (sc *ShipScanner) Update(info SceneInformation) {
 	sc.targets = info.Entities
 	sc.celestials = info.Celestials
+
+	// a target that left the scene can't be scanned anymore
+	for _, target := range sc.targets {
+		if target == sc.selectedTarget {
+			return
+		}
+	}
+	sc.selectedTarget = nil
+}
+
+// The scanner shows its status and a panel with the selected target(s)
+func (sc *ShipScanner) Widgets() []HUDWidget {
+	status := NewTextWidget(AnchorTopRight, func() []string {
+		return []string{
+			fmt.Sprintf("Scanner: %d contacts", len(sc.targets)),
+			fmt.Sprintf("Range:   %s", formatDistance(sc.Range)),
+		}
+	})
+	targets := NewTextWidget(AnchorTopRight, func() []string {
+		var lines []string
+		describe := func(kind string, target Entity) {
+			distance, closing := rangeTo(sc.ship, target)
+			lines = append(lines,
+				fmt.Sprintf("%s: %s", kind, target.Name()),
+				fmt.Sprintf("  Distance: %s", formatDistance(distance)),
+				fmt.Sprintf("  Closing:  %.2f", closing),
+			)
+			if ship, ok := target.(*Ship); ok {
+				for _, line := range ship.condition() {
+					lines = append(lines, "  "+line)
+				}
+			}
+		}
+		if target := sc.Target(); target != nil {
+			describe("Target", target)
+		}
+		if celestial := sc.Celestial(); celestial != nil {
+			describe("Celestial", celestial)
+		}
+		if len(lines) == 0 {
+			lines = []string{"No target"}
+		}
+		return lines
+	})
+	// the target view keeps its camera between frames
+	if sc.targetView == nil {
+		sc.targetView = NewTargetView(sc)
+	}
+	return []HUDWidget{status, targets, sc.targetView, NewRadarWidget(sc)}
 }
 
 func (sc *ShipScanner) Celestial() Celestial {
@@ -205,7 +392,6 @@ func (sc *ShipScanner) Celestial() Celestial {
 // "Clear Target" action should be required to have no target unless the pilot just entered the system.
 func (sc *ShipScanner) NextCelestial() {
 	if len(sc.celestials) == 0 {
-		log.Println("no celestials")
 		return
 	}
 	if sc.selectedCelest == nil {
@@ -281,9 +467,11 @@ func (sc *ShipScanner) NextTarget() {
 
 func DefaultShipEngine() *ShipEngine {
 	return &ShipEngine{
-		Acceleration: 3.0,
-		MaxVel:       1.0,
-		TurnSpeed:    3.0,
+		Thrust:    4500,
+		Torque:    50000,
+		MaxVel:    60,
+		TurnSpeed: 3,
+		Mass:      5,
 	}
 }
 
@@ -291,6 +479,7 @@ func DefaultShipScanner() *ShipScanner {
 	return &ShipScanner{
 		Accuracy: 100.0,
 		Range:    300000.0,
+		Mass:     1,
 	}
 }
 
@@ -2,24 +2,35 @@ package spacegame
 
 import (
 	"encoding/json"
+	"log"
 	"os"
 )
 
 type SolarSystem struct {
 	name       string
 	celestials []Celestial
+	orbiting   []*BaseCelestial // parents before their moons
+	time       float64          // seconds, where everything is on its orbit
+	fields     []*AsteroidField
 }
 
 type SolarSystemConfig struct {
-	Name       string
-	Celestials []CelestialConfig
+	Name           string
+	Time           float64 `json:",omitempty"`
+	Celestials     []CelestialConfig
+	AsteroidFields []AsteroidFieldConfig `json:",omitempty"`
 }
 
 func (s SolarSystem) Config() SolarSystemConfig {
-	return SolarSystemConfig{
+	config := SolarSystemConfig{
 		Name:       s.name,
+		Time:       s.time,
 		Celestials: CelestialCollection(s.celestials).Config(),
 	}
+	for _, field := range s.fields {
+		config.AsteroidFields = append(config.AsteroidFields, field.Config())
+	}
+	return config
 }
 
 func NewSolarSystem(name string, celestials ...Celestial) *SolarSystem {
@@ -27,9 +38,88 @@ func NewSolarSystem(name string, celestials ...Celestial) *SolarSystem {
 		name:       name,
 		celestials: celestials,
 	}
+	s.link()
+	s.SetTime(0)
 	return &s
 }
 
+// link finds the parents of orbiting celestials, and the order to move them in
+func (s *SolarSystem) link() {
+	byName := make(map[string]Celestial)
+	for _, c := range s.celestials {
+		byName[c.Name()] = c
+	}
+
+	s.orbiting = nil
+	added := make(map[*BaseCelestial]bool)
+	var add func(c *BaseCelestial)
+	add = func(c *BaseCelestial) {
+		if added[c] {
+			return
+		}
+		added[c] = true
+		if parent, ok := c.parent.(celestialBase); ok {
+			add(parent.base())
+		}
+		if c.orbit != nil {
+			s.orbiting = append(s.orbiting, c)
+		}
+	}
+
+	for _, c := range s.celestials {
+		b, ok := c.(celestialBase)
+		if !ok || b.base().orbit == nil || b.base().orbit.Parent == "" {
+			continue
+		}
+		celestial := b.base()
+		parent, ok := byName[celestial.orbit.Parent]
+		if !ok {
+			log.Println("Can't find", celestial.orbit.Parent, "for", celestial.name, "to orbit")
+			continue
+		}
+		celestial.parent = parent
+		if s.circles(celestial) {
+			log.Println(celestial.name, "orbits itself through", celestial.orbit.Parent)
+			celestial.parent = nil
+		}
+	}
+
+	for _, c := range s.celestials {
+		if b, ok := c.(celestialBase); ok {
+			add(b.base())
+		}
+	}
+}
+
+// Whether following the parents of a celestial leads back to it
+func (s *SolarSystem) circles(celestial *BaseCelestial) bool {
+	parent := celestial.parent
+	for i := 0; parent != nil && i <= len(s.celestials); i++ {
+		b, ok := parent.(celestialBase)
+		if !ok {
+			return false
+		}
+		if b.base() == celestial {
+			return true
+		}
+		parent = b.base().parent
+	}
+	return false
+}
+
+// SetTime moves every celestial to where its orbit takes it at time t
+func (s *SolarSystem) SetTime(t float64) {
+	s.time = t
+	for _, c := range s.orbiting {
+		c.moveTo(t)
+	}
+}
+
+// The seconds the system has been going for
+func (s *SolarSystem) Time() float64 {
+	return s.time
+}
+
 func LoadSystem(path string) (*SolarSystem, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -46,13 +136,13 @@ func (config SolarSystemConfig) load() *SolarSystem {
 	var celestials []Celestial
 
 	for _, c := range config.Celestials {
-		celestial := NewCelestial(c.Name, c.ImagePath, c.Coordinates)
-		celestials = append(celestials, celestial)
+		celestials = append(celestials, c.load())
 	}
 
-	system := &SolarSystem{
-		name:       config.Name,
-		celestials: celestials,
+	system := NewSolarSystem(config.Name, celestials...)
+	system.SetTime(config.Time)
+	for _, field := range config.AsteroidFields {
+		system.AddAsteroidField(NewAsteroidField(field))
 	}
 	return system
 }
@@ -76,3 +166,11 @@ func (s SolarSystem) Name() string {
 func (s SolarSystem) Celestials() []Celestial {
 	return s.celestials
 }
+
+func (s *SolarSystem) AddAsteroidField(field *AsteroidField) {
+	s.fields = append(s.fields, field)
+}
+
+func (s SolarSystem) AsteroidFields() []*AsteroidField {
+	return s.fields
+}
@@ -3,13 +3,18 @@ package spacegame
 import (
 	"log"
 	"math/rand"
+	"time"
 
 	"github.com/faiface/pixel"
 )
 
+// The stars are far away, even the nearest layer only moves a fraction of what the camera does
+const parallax = 1.0 / 60
+
 type Background interface {
 	Displace(float64)
-	Render()
+	Resize(bounds pixel.Rect)
+	Submit(queue *RenderQueue)
 }
 
 type star struct {
@@ -23,30 +28,54 @@ type Starscape struct {
 	camera      Camera
 	stars       []star
 	resources   []Resource
+	density     float64
 	scaleFactor float64
+	rng         *rand.Rand
+}
+
+func NewStarscape(renderer Renderer, camera Camera, density float64) *Starscape {
+	return NewSeededStarscape(renderer, camera, density, time.Now().UnixNano())
+}
+
+// Creates a starscape that always looks the same for the same seed (and screen size)
+func NewSeededStarscape(renderer Renderer, camera Camera, density float64, seed int64) *Starscape {
+	sc := &Starscape{
+		renderer:    renderer,
+		camera:      camera,
+		density:     density,
+		scaleFactor: 1.17, // TODO param like density
+		rng:         rand.New(rand.NewSource(seed)),
+	}
+	sc.populate(renderer.Bounds())
+	return sc
+}
+
+// Resize fills a new screen size with stars
+func (sc *Starscape) Resize(bounds pixel.Rect) {
+	sc.populate(bounds)
 }
 
-func NewStarscape(renderer Renderer, camera Camera, density float64) Starscape {
+// populate scatters stars over bounds, and a little beyond them
+func (sc *Starscape) populate(bounds pixel.Rect) {
 	const layerLow, layerHigh = 0.1, 2.35
 	var (
 		stars           []star
 		resourceManager ResourceManager
-		bounds          = renderer.Bounds()
 		w               = bounds.W()
 		h               = bounds.H()
-		numStars        = int(w*h*density) / 1000
+		numStars        = int(w*h*sc.density) / 1000
 		numDust         = numStars / 10
-		scaleFactor     = 1.17 // TODO param like density
+		scaleFactor     = sc.scaleFactor
+		rng             = sc.rng
 		extraW          = w*scaleFactor - w
 		extraH          = w*scaleFactor - w
 	)
-    log.Println(extraW, extraH)
-	resourceManager = renderer.ResourceManager()
+	resourceManager = sc.renderer.ResourceManager()
 	starResources := resourceManager.FindInCollection("star")
 	dustResources := resourceManager.FindInCollection("dust")
 	if len(starResources) == 0 {
 		log.Println("Could not create a starscape: No stars found")
-		return Starscape{}
+		return
 	}
 	if len(dustResources) == 0 {
 		log.Println("Error creating a starscape: No dust found -- using stars instead")
@@ -58,36 +87,31 @@ func NewStarscape(renderer Renderer, camera Camera, density float64) Starscape {
 			topLow  float64 = 0.0
 			topMult float64 = 1.00
 		)
-		resource := &starResources[rand.Intn(len(starResources))]
-		x = -extraW/2 + float64(rand.Intn(int(w*scaleFactor)))
-		y = -extraH/2 + float64(rand.Intn(int(h*scaleFactor)))
+		resource := &starResources[rng.Intn(len(starResources))]
+		x = -extraW/2 + float64(rng.Intn(int(w*scaleFactor)))
+		y = -extraH/2 + float64(rng.Intn(int(h*scaleFactor)))
 
 		// create dust
 		if i > numStars {
 			topLow = 3.7
 			topMult = 16.76
-			resource = &dustResources[rand.Intn(len(dustResources))]
+			resource = &dustResources[rng.Intn(len(dustResources))]
 		}
 
 		star := star{
 			resource: resource,
 			position: pixel.V(x, y),
-			layer:    topLow + layerLow + rand.Float64()*(layerHigh*topMult),
+			layer:    topLow + layerLow + rng.Float64()*(layerHigh*topMult),
 		}
 		stars = append(stars, star)
 	}
 
-	return Starscape{
-		renderer:    renderer,
-		camera:      camera,
-		stars:       stars,
-		resources:   starResources,
-		scaleFactor: scaleFactor,
-	}
+	sc.stars = stars
+	sc.resources = starResources
 }
 
-func (sc Starscape) Displace(dt float64) {
-	vector := sc.camera.Motion().Scaled(dt)
+func (sc *Starscape) Displace(dt float64) {
+	vector := sc.camera.Motion().Scaled(dt * parallax)
 	if vector.Len() == 0 {
 		return
 	}
@@ -106,34 +130,35 @@ func (sc Starscape) Displace(dt float64) {
 	}
 }
 
-func (sc Starscape) Render() {
-	// TODO: Sort by layer!
+// Stars are sorted by their parallax layer, slow (distant) stars are drawn first.
+// They are too far away to be affected by the camera's zoom.
+func (sc *Starscape) Submit(queue *RenderQueue) {
 	for _, star := range sc.stars {
-		sc.renderer.Render(star.resource.entity, star.position)
+		queue.SubmitEntity(LayerBackground, star.layer, star.resource.entity, star.position, 1)
 	}
 }
 
-func (sc Starscape) recreate(starIndex int) {
+func (sc *Starscape) recreate(starIndex int) {
 	candidatePosition := sc.stars[starIndex].position
 	bounds := sc.renderer.Bounds()
 	h := bounds.H()
 	w := bounds.W()
 	extraH := h*sc.scaleFactor - h
 	extraW := w*sc.scaleFactor - w
-	sc.stars[starIndex].resource = &sc.resources[rand.Intn(len(sc.resources))]
+	sc.stars[starIndex].resource = &sc.resources[sc.rng.Intn(len(sc.resources))]
 
 	if candidatePosition.X > w+extraW {
 		candidatePosition.X = 0-extraW
-		candidatePosition.Y = float64(rand.Intn(int(h)))
+		candidatePosition.Y = float64(sc.rng.Intn(int(h)))
 	} else if candidatePosition.X < 0-extraW {
 		candidatePosition.X = w+extraW
-		candidatePosition.Y = float64(rand.Intn(int(h)))
+		candidatePosition.Y = float64(sc.rng.Intn(int(h)))
 	}
 	if candidatePosition.Y > h+extraH {
-		candidatePosition.X = float64(rand.Intn(int(w)))
+		candidatePosition.X = float64(sc.rng.Intn(int(w)))
 		candidatePosition.Y = 0-extraH
 	} else if candidatePosition.Y < 0-extraH {
-		candidatePosition.X = float64(rand.Intn(int(w)))
+		candidatePosition.X = float64(sc.rng.Intn(int(w)))
 		candidatePosition.Y = h+extraH
 	}
 
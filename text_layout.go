@@ -0,0 +1,147 @@
+package spacegame
+
+import (
+	"image/color"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+
+	"github.com/faiface/pixel"
+)
+
+type TextAlign int
+
+const (
+	AlignLeft   TextAlign = iota // lines start at the position
+	AlignCenter                  // lines are centered on the position
+	AlignRight                   // lines end at the position
+)
+
+// TextStyle describes how Renderer.Text draws text
+type TextStyle struct {
+	Font      string  // A font registered with the resource manager, or empty for the built-in 7x13 font
+	Size      float64 // Ignored by the built-in font
+	Color     color.Color
+	Align     TextAlign
+	WrapWidth float64 // Lines longer than this are wrapped at spaces, 0 never wraps
+}
+
+var DefaultTextStyle = TextStyle{
+	Color: colornames.White,
+}
+
+// The style used by HUD widgets
+var HUDTextStyle = TextStyle{
+	Font:  "Go-Mono",
+	Size:  12,
+	Color: colornames.White,
+}
+
+// Returns the same style with a different color
+func (ts TextStyle) WithColor(color color.Color) TextStyle {
+	ts.Color = color
+	return ts
+}
+
+// Returns the same style with a different alignment
+func (ts TextStyle) WithAlign(align TextAlign) TextStyle {
+	ts.Align = align
+	return ts
+}
+
+// the fonts that were missing, so each is only logged the first time it's drawn with
+var missingFonts sync.Map
+
+// textFace finds the face for a style, falling back to the built-in font
+func textFace(resourceManager ResourceManager, style TextStyle) font.Face {
+	if style.Font == "" {
+		return basicfont.Face7x13
+	}
+	face, err := resourceManager.Font(style.Font, style.Size)
+	if err != nil {
+		if _, logged := missingFonts.LoadOrStore(style.Font, true); !logged {
+			log.Println(err, "-- using the built-in font")
+		}
+		return basicfont.Face7x13
+	}
+	return face
+}
+
+func textWidth(face font.Face, s string) float64 {
+	return float64(font.MeasureString(face, s).Ceil())
+}
+
+func lineHeight(face font.Face) float64 {
+	return float64(face.Metrics().Height.Ceil())
+}
+
+// A line of text and where its baseline starts
+type textLine struct {
+	text string
+	dot  pixel.Vec
+}
+
+// layoutText breaks text into lines, wrapping them if necessary, and aligns them.
+// position is the baseline of the first line, following lines go down the screen.
+func layoutText(face font.Face, txt string, position pixel.Vec, style TextStyle) []textLine {
+	var lines []string
+	for _, line := range strings.Split(txt, "\n") {
+		lines = append(lines, wrapLine(face, line, style.WrapWidth)...)
+	}
+
+	var (
+		height = lineHeight(face)
+		layout = make([]textLine, len(lines))
+	)
+	for i, line := range lines {
+		dot := pixel.V(position.X, position.Y-float64(i)*height)
+		switch style.Align {
+		case AlignCenter:
+			dot.X -= math.Floor(textWidth(face, line) / 2)
+		case AlignRight:
+			dot.X -= textWidth(face, line)
+		}
+		layout[i] = textLine{line, dot}
+	}
+	return layout
+}
+
+// measureText returns the size of a block of text
+func measureText(face font.Face, txt string, style TextStyle) pixel.Vec {
+	lines := layoutText(face, txt, pixel.ZV, style)
+	width := 0.0
+	for _, line := range lines {
+		width = math.Max(width, textWidth(face, line.text))
+	}
+	return pixel.V(width, float64(len(lines))*lineHeight(face))
+}
+
+// wrapLine splits a line at spaces so that the pieces fit in width.
+// Words that are too long by themselves get their own line.
+func wrapLine(face font.Face, line string, width float64) []string {
+	if width <= 0 || textWidth(face, line) <= width {
+		return []string{line}
+	}
+
+	var (
+		lines   []string
+		current string
+	)
+	for _, word := range strings.Fields(line) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && textWidth(face, candidate) > width {
+			lines = append(lines, current)
+			candidate = word
+		}
+		current = candidate
+	}
+	return append(lines, current)
+}
@@ -0,0 +1,175 @@
+package spacegame
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// Turrets are drawn this many pixels across, at zoom 1
+const turretSize = 8
+
+// What a turret points at
+type TurretMode int
+
+const (
+	TurretTracking TurretMode = iota // the scanner's target, leading it
+	TurretMouse                      // wherever the pilot is pointing
+	turretModes
+)
+
+func (mode TurretMode) String() string {
+	switch mode {
+	case TurretTracking:
+		return "Tracking"
+	case TurretMouse:
+		return "Mouse"
+	}
+	return "Unknown"
+}
+
+// A turret is a weapon on a mount of its own, it turns to aim independently of the hull.
+// It can only turn so far to either side of the way it faces, and only so fast.
+type ShipTurret struct {
+	ShipWeapon
+	Mount     pixel.Vec // from the center of the hull, with the nose up
+	Facing    float64   // radians from the nose, the way the turret points at rest
+	Arc       float64   // radians the turret turns to either side of Facing, π or more is all the way round
+	TurnSpeed float64   // radians per second
+	Sprite    string    `json:",omitempty"` // the turret's image, "turret" if there is none
+	offset    float64   // radians the turret is turned from Facing
+	mode      TurretMode
+	aim       *pixel.Vec // the point in the world the pilot is pointing at, if they are
+}
+
+func (st ShipTurret) Name() string {
+	return "turret"
+}
+
+func (st *ShipTurret) Activate(command pilotAction) {
+	switch command.key {
+	case actionFire:
+		st.ShipWeapon.Activate(command)
+	case actionTurretMode:
+		st.mode = (st.mode + 1) % turretModes
+	}
+}
+
+// Shots come out of the end of the barrel, instead of the nose
+func (st *ShipTurret) Install(ship *Ship) {
+	st.ShipWeapon.Install(ship)
+	st.barrel = func() (pixel.Vec, float64) {
+		muzzle := pixel.V(0, turretSize/2).Rotated(st.Angle())
+		return st.Position().Add(muzzle), st.Angle()
+	}
+}
+
+func (st *ShipTurret) Mode() TurretMode {
+	return st.mode
+}
+
+func (st *ShipTurret) SetMode(mode TurretMode) {
+	st.mode = mode
+}
+
+// Aim at a point in the world, while the turret follows the mouse
+func (st *ShipTurret) Aim(point pixel.Vec) {
+	st.aim = &point
+}
+
+// The way the turret points, like a ship it points up at angle 0
+func (st *ShipTurret) Angle() float64 {
+	return normalizeAngle(st.ship.Angle() + st.Facing + st.offset)
+}
+
+// Where the mount is, in the world
+func (st *ShipTurret) Position() pixel.Vec {
+	return st.ship.Coordinates().Add(st.Mount.Rotated(st.ship.Angle()))
+}
+
+// Update turns the turret toward what it is aiming at, then fires if the trigger is held
+func (st *ShipTurret) Update(info SceneInformation) {
+	desired := st.Facing
+	if point, ok := st.target(); ok {
+		// the point's direction, relative to the hull with the nose up
+		desired = point.Sub(st.Position()).Angle() - math.Pi/2 - st.ship.Angle()
+	}
+	st.turn(normalizeAngle(desired-st.Facing), info.Elapsed)
+	st.ShipWeapon.Update(info)
+}
+
+// The point the turret should aim at, if there is one
+func (st *ShipTurret) target() (pixel.Vec, bool) {
+	switch st.mode {
+	case TurretMouse:
+		if st.aim != nil {
+			return *st.aim, true
+		}
+	case TurretTracking:
+		scanner, ok := st.ship.systems["scanner"].(*ShipScanner)
+		if !ok || scanner.Target() == nil {
+			return pixel.ZV, false
+		}
+		// lead the target by the time the shots take to get there
+		target := scanner.Target()
+		offset := target.Coordinates().Sub(st.Position())
+		flight := offset.Len() / math.Max(st.ProjectileSpeed, 1)
+		return target.Coordinates().Add(target.Velocity().Sub(st.ship.Velocity()).Scaled(flight)), true
+	}
+	return pixel.ZV, false
+}
+
+// turn moves the turret toward offset from Facing, as far as it can in dt.
+// Turrets with a limited arc stop at its ends, and never turn through the back to get somewhere.
+func (st *ShipTurret) turn(offset, dt float64) {
+	step := st.TurnSpeed * dt
+	if st.Arc >= math.Pi {
+		turn := normalizeAngle(offset - st.offset)
+		st.offset = normalizeAngle(st.offset + math.Max(-step, math.Min(step, turn)))
+		return
+	}
+	offset = math.Max(-st.Arc, math.Min(st.Arc, offset))
+	st.offset += math.Max(-step, math.Min(step, offset-st.offset))
+}
+
+// The turret shows its capacitor, or the rounds it has left, and what it is aiming at
+func (st *ShipTurret) Widgets() []HUDWidget {
+	return []HUDWidget{NewTextWidget(AnchorBottomLeft, func() []string {
+		if st.Capacity > 0 {
+			return []string{fmt.Sprintf("Turret:   %s %4.0f %s", gauge(st.energy, st.Capacity, 10), st.energy, st.mode)}
+		}
+		return []string{fmt.Sprintf("Turret:   %d rounds %s", st.Ammo, st.mode)}
+	})}
+}
+
+// What the renderer sees of a turret, its sprite where the mount is
+type turretView struct {
+	turret *ShipTurret
+}
+
+func (tv turretView) Name() string {
+	if tv.turret.Sprite == "" {
+		return "turret"
+	}
+	return tv.turret.Sprite
+}
+
+func (tv turretView) Angle() float64 {
+	return tv.turret.Angle()
+}
+
+func (tv turretView) Bounds() pixel.Rect {
+	return pixel.R(-turretSize/2, -turretSize/2, turretSize/2, turretSize/2)
+}
+
+func (tv turretView) Coordinates() pixel.Vec {
+	return tv.turret.Position()
+}
+
+func (tv turretView) Velocity() pixel.Vec {
+	return tv.turret.ship.Velocity()
+}
+
+// Turrets go where their ship goes
+func (tv turretView) Translate(pixel.Vec) {}
@@ -0,0 +1,106 @@
+package spacegame
+
+import (
+	"image/color"
+
+	"golang.org/x/image/colornames"
+
+	"github.com/faiface/pixel"
+)
+
+// A Viewport is a part of the screen with its own camera, like a picture-in-picture.
+// Nothing it draws goes outside of it, and it is cleared with its own color.
+type Viewport struct {
+	camera     Camera
+	clearColor color.Color
+}
+
+func NewViewport(camera Camera, clearColor color.Color) *Viewport {
+	return &Viewport{
+		camera:     camera,
+		clearColor: clearColor,
+	}
+}
+
+func (vp *Viewport) Camera() Camera {
+	return vp.camera
+}
+
+func (vp *Viewport) SetCamera(camera Camera) {
+	vp.camera = camera
+}
+
+// Render clears rect of renderer and lets draw fill it, through the viewport's camera
+func (vp *Viewport) Render(renderer Renderer, rect pixel.Rect, draw func(renderer Renderer, camera Camera)) {
+	renderer.Clip(rect, func(clipped Renderer) {
+		clipped.Clear()
+		if vp.clearColor != nil {
+			bounds := clipped.Bounds().Vertices()
+			clipped.Polygon(bounds[:], 0, vp.clearColor)
+		}
+		if vp.camera != nil {
+			draw(clipped, vp.camera)
+		}
+	})
+}
+
+// The TargetView shows the scanner's target up close, in an inset under the target panel
+type TargetView struct {
+	scanner  *ShipScanner
+	viewport *Viewport
+	target   Entity // that the camera follows
+	size     float64
+}
+
+func NewTargetView(scanner *ShipScanner) *TargetView {
+	return &TargetView{
+		scanner:  scanner,
+		viewport: NewViewport(nil, color.RGBA{0, 16, 24, 255}),
+		size:     120,
+	}
+}
+
+func (tv *TargetView) Anchor() HUDAnchor {
+	return AnchorTopRight
+}
+
+// The view is hidden when there is no target
+func (tv *TargetView) Size(renderer Renderer) pixel.Vec {
+	if tv.scanner.Target() == nil {
+		return pixel.ZV
+	}
+	return pixel.V(tv.size, tv.size)
+}
+
+func (tv *TargetView) Render(renderer Renderer, bounds pixel.Rect) {
+	target := tv.scanner.Target()
+	if target == nil {
+		return
+	}
+	if target != tv.target {
+		tv.target = target
+		camera := NewChaseCamera(target)
+		camera.lookAhead = 0
+		// fill most of the view with the target
+		size := target.Bounds().Size()
+		camera.ZoomBy(tv.size * 0.6 / size.Len())
+		tv.viewport.SetCamera(camera)
+	}
+	tv.viewport.Camera().(*ChaseCamera).Snap()
+
+	tv.viewport.Render(renderer, bounds, func(renderer Renderer, camera Camera) {
+		// whatever is around the target is in view as well
+		for _, celestial := range tv.scanner.celestials {
+			camera.Render(renderer, celestial)
+		}
+		for _, entity := range tv.scanner.targets {
+			camera.Render(renderer, entity)
+		}
+		if tv.scanner.ship != nil {
+			camera.Render(renderer, tv.scanner.ship)
+		}
+	})
+
+	frame := bounds.Vertices()
+	renderer.Polygon(frame[:], 1, factionColor(target, colornames.Lightgreen))
+}
@@ -0,0 +1,113 @@
+package spacegame
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/faiface/pixel"
+)
+
+// The weapon fires projectiles out of the front of the ship, for as long as the trigger is held.
+// Every shot costs energy from the weapon's capacitor, which recharges, or rounds of ammo, which don't.
+type ShipWeapon struct {
+	Damage          float64 // to the hull of whatever a projectile hits
+	FireRate        float64 // shots per second
+	ProjectileSpeed float64 // pixels per second, on top of the ship's velocity
+	Lifetime        float64 // seconds before a projectile fizzles out
+	Spread          float64 // radians, shots go out up to this far to either side of the ship's heading
+	EnergyCost      float64 `json:",omitempty"` // of a shot
+	Capacity        float64 `json:",omitempty"` // energy the capacitor holds
+	Recharge        float64 `json:",omitempty"` // energy per second
+	AmmoCost        int     `json:",omitempty"` // rounds a shot uses
+	Ammo            int     `json:",omitempty"` // rounds left
+	Mass            float64 `json:",omitempty"` // tonnes
+	ship            *Ship
+	energy          float64
+	cooldown        float64 // seconds until the weapon can fire again
+	trigger         bool    // the pilot wants to fire, since the last update
+	rng             *rand.Rand
+	barrel          func() (pixel.Vec, float64) // where shots come out and which way, the nose unless it's on a turret
+}
+
+func (sw ShipWeapon) Name() string {
+	return "weapon"
+}
+
+func (sw *ShipWeapon) Activate(command pilotAction) {
+	if command.key == actionFire {
+		sw.trigger = true
+	}
+}
+
+func (sw *ShipWeapon) Install(ship *Ship) {
+	sw.ship = ship
+	sw.energy = sw.Capacity
+}
+
+func (sw *ShipWeapon) weight() float64 {
+	return sw.Mass
+}
+
+// Update recharges the capacitor, and fires as many shots as the fire rate allows while the trigger is held
+func (sw *ShipWeapon) Update(info SceneInformation) {
+	if sw.rng == nil {
+		sw.rng = rand.New(rand.NewSource(info.Seed))
+	}
+	sw.energy = math.Min(sw.Capacity, sw.energy+sw.Recharge*info.Elapsed)
+	sw.cooldown -= info.Elapsed
+
+	trigger := sw.trigger
+	sw.trigger = false
+	if !trigger || sw.FireRate <= 0 {
+		// the weapon doesn't save up shots while it isn't firing
+		sw.cooldown = math.Max(sw.cooldown, 0)
+		return
+	}
+	for sw.cooldown <= 0 && sw.loaded() {
+		sw.fire(info.Projectiles)
+		sw.cooldown += 1 / sw.FireRate
+	}
+}
+
+// Whether the weapon can pay for another shot
+func (sw *ShipWeapon) loaded() bool {
+	return sw.energy >= sw.EnergyCost && sw.Ammo >= sw.AmmoCost
+}
+
+func (sw *ShipWeapon) fire(projectiles *ProjectilePool) {
+	sw.energy -= sw.EnergyCost
+	sw.Ammo -= sw.AmmoCost
+	if projectiles == nil {
+		return
+	}
+
+	// ships point up at angle 0, shots come out of the nose
+	muzzle, heading := sw.ship.Coordinates().Add(pixel.V(0, sw.ship.Bounds().H()/2).Rotated(sw.ship.Angle())), sw.ship.Angle()
+	if sw.barrel != nil {
+		muzzle, heading = sw.barrel()
+	}
+	angle := heading + (sw.rng.Float64()*2-1)*sw.Spread
+	projectiles.Fire(Shot{
+		Owner:    sw.ship,
+		Position: muzzle,
+		Velocity: sw.ship.Velocity().Add(pixel.V(0, sw.ProjectileSpeed).Rotated(angle)),
+		Damage:   sw.Damage,
+		Lifetime: sw.Lifetime,
+	})
+}
+
+// The energy in the capacitor, and how much it holds
+func (sw *ShipWeapon) Energy() (energy, capacity float64) {
+	return sw.energy, sw.Capacity
+}
+
+// The weapon shows its capacitor, or the rounds it has left
+func (sw *ShipWeapon) Widgets() []HUDWidget {
+	return []HUDWidget{NewTextWidget(AnchorBottomLeft, func() []string {
+		if sw.Capacity > 0 {
+			return []string{fmt.Sprintf("Weapon:   %s %4.0f", gauge(sw.energy, sw.Capacity, 10), sw.energy)}
+		}
+		return []string{fmt.Sprintf("Weapon:   %d rounds", sw.Ammo)}
+	})}
+}
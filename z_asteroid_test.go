@@ -0,0 +1,130 @@
+package spacegame
+
+import (
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+var testField = AsteroidFieldConfig{
+	Name:    "Belt",
+	Region:  pixel.R(1000, -1000, 3000, 1000),
+	Density: 40,
+	MinSize: 6,
+	MaxSize: 36,
+	Seed:    46,
+}
+
+func TestAsteroidStreaming(t *testing.T) {
+	field := NewAsteroidField(testField)
+	spawned, _ := field.Stream(pixel.ZV)
+	if len(spawned) == 0 {
+		t.Fatal("no asteroids came in near the field")
+	}
+	for _, asteroid := range spawned {
+		if !testField.Region.Contains(asteroid.Coordinates()) {
+			t.Errorf("an asteroid is at %v, outside of the field", asteroid.Coordinates())
+		}
+		if asteroid.Coordinates().Len() > streamRange+asteroidChunk {
+			t.Errorf("an asteroid came in at %v, out of range", asteroid.Coordinates())
+		}
+	}
+
+	// far away they're gone, and when the player comes back they're the same again
+	_, despawned := field.Stream(pixel.V(-10000, 0))
+	if len(despawned) != len(spawned) || len(field.Asteroids()) != 0 {
+		t.Errorf("%d of %d asteroids went away, %d are left", len(despawned), len(spawned), len(field.Asteroids()))
+	}
+	again, _ := field.Stream(pixel.ZV)
+	if len(again) != len(spawned) {
+		t.Fatalf("%d asteroids came back, there were %d", len(again), len(spawned))
+	}
+	positions := make(map[pixel.Vec]float64)
+	for _, asteroid := range spawned {
+		positions[asteroid.Coordinates()] = asteroid.radius
+	}
+	for _, asteroid := range again {
+		if radius, ok := positions[asteroid.Coordinates()]; !ok || radius != asteroid.radius {
+			t.Errorf("a different asteroid came back at %v", asteroid.Coordinates())
+		}
+	}
+
+	// the whole field is made up the same, whichever way the player comes from
+	fromLeft, fromRight := NewAsteroidField(testField), NewAsteroidField(testField)
+	for x := 0.0; x <= 4000; x += 500 {
+		fromLeft.Stream(pixel.V(x, 0))
+		fromRight.Stream(pixel.V(4000-x, 0))
+	}
+	for c, asteroids := range fromLeft.chunks {
+		if other := fromRight.chunks[c]; len(other) != len(asteroids) || len(asteroids) > 0 && other[0].position != asteroids[0].position {
+			t.Errorf("chunk %v came out differently", c)
+		}
+	}
+}
+
+func TestAsteroidsInScene(t *testing.T) {
+	system := NewSolarSystem("Belt")
+	system.AddAsteroidField(NewAsteroidField(testField))
+	player := NewPlayer("Test", loadTestResources())
+	renderer := NewImageRenderer(320, 240, loadTestResources())
+	scene := NewSpaceScene(system, player, renderer, testSeed)
+
+	ship := player.Ship()
+	scene.tick(1.0 / 60)
+	asteroids := len(scene.entities) - 1
+	if asteroids != len(system.AsteroidFields()[0].Asteroids()) || asteroids == 0 {
+		t.Fatalf("%d asteroids in the scene", asteroids)
+	}
+
+	// the scanner can target them
+	scene.tick(1.0 / 60)
+	scanner := ship.systems["scanner"].(*ShipScanner)
+	scanner.NextTarget()
+	scanner.NextTarget()
+	asteroid, ok := scanner.Target().(*Asteroid)
+	if !ok {
+		t.Fatalf("the scanner targeted %v", scanner.Target())
+	}
+
+	// and the ship bounces off them without pushing them
+	position := asteroid.Coordinates()
+	ship.Translate(position.Sub(ship.Coordinates()).Add(pixel.V(-80, 0)))
+	ship.velocity = pixel.V(200, 0)
+	for i := 0; i < 60; i++ {
+		scene.tick(1.0 / 60)
+	}
+	if ship.Velocity().X >= 0 || asteroid.Coordinates() != position {
+		t.Errorf("the ship is going %v after flying into an asteroid, which is at %v", ship.Velocity(), asteroid.Coordinates())
+	}
+
+	// flying away drops them, and the target with them
+	ship.Translate(pixel.V(-20000, 0))
+	scene.tick(1.0 / 60)
+	scene.tick(1.0 / 60)
+	if len(scene.entities) != 1 || scanner.Target() != nil {
+		t.Errorf("%d entities left in the scene, targeting %v", len(scene.entities), scanner.Target())
+	}
+}
+
+func TestAsteroidGolden(t *testing.T) {
+	field := NewAsteroidField(AsteroidFieldConfig{Region: pixel.R(-160, -120, 160, 120), Density: 150, MinSize: 4, MaxSize: 40, Seed: 3})
+	field.Stream(pixel.ZV)
+
+	// overlapping asteroids are drawn in the same order every time
+	again := NewAsteroidField(field.Config())
+	again.Stream(pixel.ZV)
+	for i, asteroid := range again.Asteroids() {
+		if asteroid.Coordinates() != field.Asteroids()[i].Coordinates() {
+			t.Fatalf("asteroid %d is at %v, and at %v the time before", i, asteroid.Coordinates(), field.Asteroids()[i].Coordinates())
+		}
+	}
+
+	renderer := NewImageRenderer(320, 240, loadTestResources())
+	camera := NewChaseCamera(NewShip("Starbridge"))
+	camera.lookAhead = 0
+	camera.Snap()
+	for _, asteroid := range field.Asteroids() {
+		camera.Render(renderer, asteroid)
+	}
+	compareGolden(t, "asteroids", renderer.Image())
+}
@@ -0,0 +1,108 @@
+package spacegame
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// autopilotScene puts the player's ship in a system with a planet, and engages its autopilot
+func autopilotScene(t *testing.T, mode AutopilotMode, target func(*SpaceScene) Entity) (*SpaceScene, *Ship) {
+	planet := CelestialConfig{Name: "Planet", Coordinates: pixel.V(2000, 1500), Radius: 64, Mass: 6000}.load()
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Autopilot", planet), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+
+	ship := player.Ship()
+	ship.velocity = pixel.V(-80, 30)
+	ship.angle = 2
+	ship.systems["engine"].(*ShipEngine).Autopilot().Engage(mode, target(scene))
+	return scene, ship
+}
+
+// fly the scene for a while, the autopilot may only press what a pilot could
+func fly(t *testing.T, scene *SpaceScene, ship *Ship, seconds float64) {
+	autopilot := ship.systems["engine"].(*ShipEngine).Autopilot()
+	for i := 0; i < int(seconds*60); i++ {
+		for _, command := range autopilot.Commands(1.0 / 60) {
+			switch command.key {
+			case actionAccel, actionTurnLeft, actionTurnRight:
+			default:
+				t.Fatalf("the autopilot pressed %v", command)
+			}
+		}
+		scene.tick(1.0 / 60)
+	}
+}
+
+func TestAutopilotApproach(t *testing.T) {
+	scene, ship := autopilotScene(t, AutopilotApproach, func(scene *SpaceScene) Entity {
+		return scene.system.Celestials()[0]
+	})
+	fly(t, scene, ship, 40)
+
+	planet := scene.system.Celestials()[0]
+	distance := ship.Coordinates().Sub(planet.Coordinates()).Len()
+	if distance < planet.Radius() || distance > planet.Radius()+landingMargin+10 {
+		t.Errorf("the ship stopped %v from the planet's center", distance)
+	}
+	if speed := ship.Velocity().Len(); speed > 2*speedTolerance {
+		t.Errorf("the ship is still going %v", speed)
+	}
+	if hull, max := ship.Hull(); hull != max {
+		t.Errorf("the ship crashed on the way, %v of %v hull left", hull, max)
+	}
+}
+
+func TestAutopilotIntercept(t *testing.T) {
+	var target *Ship
+	scene, ship := autopilotScene(t, AutopilotIntercept, func(scene *SpaceScene) Entity {
+		target = NewShip("Target")
+		target.Translate(pixel.V(-1500, 800))
+		target.velocity = pixel.V(40, 25)
+		scene.entities = append(scene.entities, target)
+		return target
+	})
+	fly(t, scene, ship, 40)
+
+	distance := ship.Coordinates().Sub(target.Coordinates()).Len()
+	if distance > 120 {
+		t.Errorf("the ship is %v from its target", distance)
+	}
+	if relative := ship.Velocity().Sub(target.Velocity()).Len(); relative > 2*speedTolerance {
+		t.Errorf("the ship is going %v relative to its target", relative)
+	}
+}
+
+func TestAutopilotMatchAndHold(t *testing.T) {
+	var target *Ship
+	scene, ship := autopilotScene(t, AutopilotMatch, func(scene *SpaceScene) Entity {
+		target = NewShip("Target")
+		target.Translate(pixel.V(300, 0))
+		target.velocity = pixel.V(0, 120)
+		scene.entities = append(scene.entities, target)
+		return target
+	})
+	fly(t, scene, ship, 10)
+	if relative := ship.Velocity().Sub(target.Velocity()).Len(); relative > speedTolerance {
+		t.Errorf("the ship is going %v relative to its target", relative)
+	}
+
+	autopilot := ship.systems["engine"].(*ShipEngine).Autopilot()
+	autopilot.Engage(AutopilotHold, nil)
+	hold := ship.Coordinates()
+	fly(t, scene, ship, 30)
+	// it settles as close as it creeps slower than its speed tolerance
+	if drift := ship.Coordinates().Sub(hold).Len(); drift > speedTolerance*settleTime || ship.Velocity().Len() > 2*speedTolerance {
+		t.Errorf("the ship was holding at %v, and is %v away going %v", hold, drift, ship.Velocity())
+	}
+
+	// flying by hand takes over
+	ship.Process(pilotAction{actionTurnLeft, 1.0 / 60})
+	if autopilot.Mode() != AutopilotOff {
+		t.Errorf("the autopilot is still on %v", autopilot)
+	}
+	if math.IsNaN(ship.Angle()) {
+		t.Error("the ship has no heading")
+	}
+}
@@ -0,0 +1,143 @@
+package spacegame
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestCameraConversions(t *testing.T) {
+	renderer := NewImageRenderer(400, 300, NewStandardResourceManager("data/resources"))
+	ship := NewShip("Starbridge")
+	ship.Translate(pixel.V(1000, -500))
+	camera := NewChaseCamera(ship)
+	camera.Update(1.0 / 60)
+
+	if at := camera.WorldToScreen(renderer, ship.Coordinates()); at != renderer.Center() {
+		t.Errorf("target is at %v, not in the center", at)
+	}
+
+	camera.ZoomBy(2)
+	world := pixel.V(1100, -450)
+	screen := camera.WorldToScreen(renderer, world)
+	if screen != pixel.V(400, 250) {
+		t.Errorf("%v is at %v on the screen at zoom 2", world, screen)
+	}
+	if back := camera.ScreenToWorld(renderer, screen); back.Sub(world).Len() > 1e-9 {
+		t.Errorf("%v went to %v and back to %v", world, screen, back)
+	}
+
+	camera.ZoomBy(100)
+	if camera.Zoom() != camera.maxZoom {
+		t.Errorf("zoomed in to %v, past the limit", camera.Zoom())
+	}
+	camera.ZoomBy(0.0001)
+	if camera.Zoom() != camera.minZoom {
+		t.Errorf("zoomed out to %v, past the limit", camera.Zoom())
+	}
+}
+
+func TestCameraFollow(t *testing.T) {
+	ship := NewShip("Starbridge")
+	camera := NewChaseCamera(ship)
+	camera.Update(1.0 / 60)
+
+	// the camera catches up with a jump without overshooting it
+	ship.Translate(pixel.V(100, 0))
+	previous := 0.0
+	for i := 0; i < 120; i++ {
+		camera.Update(1.0 / 60)
+		if camera.position.X < previous || camera.position.X > 100 {
+			t.Fatalf("camera went from %v to %v", previous, camera.position.X)
+		}
+		previous = camera.position.X
+	}
+	if math.Abs(previous-100) > 1 {
+		t.Errorf("camera is at %v after two seconds", previous)
+	}
+
+	// and looks ahead of a moving target, but not too far
+	ship.velocity = pixel.V(0, 1000)
+	for i := 0; i < 600; i++ {
+		camera.Update(1.0 / 60)
+	}
+	if ahead := camera.position.Sub(ship.Coordinates()); math.Abs(ahead.Y-camera.maxLookAhead) > 1 {
+		t.Errorf("camera is %v ahead of its target", ahead)
+	}
+}
+
+func TestScreenShakeDeterministic(t *testing.T) {
+	shaken := func(seed int64) []pixel.Vec {
+		camera := NewEffectCamera(NewChaseCamera(NewShip("Starbridge")), seed)
+		camera.Shake(0.8)
+		var views []pixel.Vec
+		for i := 0; i < 90; i++ {
+			camera.Update(1.0 / 60)
+			views = append(views, camera.View().Position)
+		}
+		return views
+	}
+
+	a, b := shaken(7), shaken(7)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("update %d: %v and %v differ with the same seed", i, a[i], b[i])
+		}
+	}
+	if a[0] == pixel.ZV {
+		t.Error("the camera did not shake")
+	}
+	if last := a[len(a)-1]; last != pixel.ZV {
+		t.Errorf("the camera is still shaking at %v after the trauma wore off", last)
+	}
+}
+
+func TestCinematic(t *testing.T) {
+	waypoints := []CameraView{
+		{pixel.V(0, 0), 0.5},
+		{pixel.V(100, 100), 1},
+		{pixel.V(200, 0), 2},
+	}
+	cinematic := NewCinematic(2, waypoints...)
+	for _, check := range []struct {
+		t    float64
+		view CameraView
+	}{{0, waypoints[0]}, {0.5, waypoints[1]}, {1, waypoints[2]}} {
+		if view := cinematic.At(check.t); view.Position.Sub(check.view.Position).Len() > 1e-9 || view.Zoom != check.view.Zoom {
+			t.Errorf("view at %v is %v, expected %v", check.t, view, check.view)
+		}
+	}
+
+	// the camera returns to the base camera when the cinematic is over
+	camera := NewEffectCamera(NewChaseCamera(NewShip("Starbridge")), 1)
+	camera.Push(cinematic)
+	camera.Update(1)
+	if camera.View().Position == pixel.ZV {
+		t.Error("the cinematic did not move the camera")
+	}
+	camera.Update(1)
+	if view := camera.View(); view.Position != pixel.ZV || view.Zoom != 1 {
+		t.Errorf("the camera is at %v after the cinematic", view)
+	}
+}
+
+func TestFreeLook(t *testing.T) {
+	ship := NewShip("Starbridge")
+	camera := NewEffectCamera(NewChaseCamera(ship), 1)
+	camera.Update(1.0 / 60)
+
+	camera.ToggleFreeLook()
+	camera.Pan(pixel.V(1, 0), 0.5)
+	ship.Translate(pixel.V(0, 1000))
+	camera.Update(1.0 / 60)
+	if position := camera.View().Position; position != pixel.V(300, 0) {
+		t.Errorf("free-look camera is at %v", position)
+	}
+
+	camera.ToggleFreeLook()
+	camera.Update(1.0 / 60)
+	if position := camera.View().Position; position == pixel.V(300, 0) {
+		t.Error("the camera is still detached")
+	}
+}
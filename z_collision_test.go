@@ -0,0 +1,183 @@
+package spacegame
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// A collider with any shape
+type testCollider struct {
+	BaseEntity
+	shape       Shape
+	layer, mask CollisionLayer
+}
+
+func (tc *testCollider) Shape() Shape                   { return tc.shape }
+func (tc *testCollider) CollisionLayer() CollisionLayer { return tc.layer }
+func (tc *testCollider) CollisionMask() CollisionLayer  { return tc.mask }
+
+func circle(x, y, radius float64) *testCollider {
+	return &testCollider{shape: CircleShape{pixel.V(x, y), radius}, layer: CollisionShips, mask: CollisionAll}
+}
+
+func box(x, y, w, h, angle float64) *testCollider {
+	return &testCollider{shape: BoxShape{pixel.V(x, y), pixel.V(w/2, h/2), angle}, layer: CollisionShips, mask: CollisionAll}
+}
+
+func TestCollideShapes(t *testing.T) {
+	for _, check := range []struct {
+		name        string
+		a, b        Shape
+		normal      pixel.Vec
+		penetration float64 // 0 when they don't touch
+		point       pixel.Vec
+	}{
+		{"circles", CircleShape{pixel.ZV, 10}, CircleShape{pixel.V(15, 0), 10}, pixel.V(1, 0), 5, pixel.V(7.5, 0)},
+		{"apart circles", CircleShape{pixel.ZV, 10}, CircleShape{pixel.V(0, 25), 10}, pixel.ZV, 0, pixel.ZV},
+		{"box and circle", box(0, 0, 20, 20, 0).shape, CircleShape{pixel.V(0, -14), 5}, pixel.V(0, -1), 1, pixel.V(0, -10)},
+		{"circle and box", CircleShape{pixel.V(0, -14), 5}, box(0, 0, 20, 20, 0).shape, pixel.V(0, 1), 1, pixel.V(0, -10)},
+		{"circle inside box", box(0, 0, 20, 40, 0).shape, CircleShape{pixel.V(6, 0), 1}, pixel.V(1, 0), 5, pixel.V(6, 0)},
+		// the corner of the turned box reaches 14.1 to the right
+		{"turned box and circle", box(0, 0, 20, 20, math.Pi/4).shape, CircleShape{pixel.V(18, 0), 5}, pixel.V(1, 0), 1.14, pixel.V(14.14, 0)},
+		{"upright box misses circle", box(0, 0, 20, 20, 0).shape, CircleShape{pixel.V(18, 0), 5}, pixel.ZV, 0, pixel.ZV},
+		{"boxes", box(0, 0, 20, 20, 0).shape, box(18, 5, 20, 20, 0).shape, pixel.V(1, 0), 2, pixel.V(9, 10)},
+		{"apart boxes", box(0, 0, 20, 20, 0).shape, box(0, -25, 20, 20, 0).shape, pixel.ZV, 0, pixel.ZV},
+		{"turned boxes", box(0, 0, 20, 20, 0).shape, box(23, 0, 20, 20, math.Pi/4).shape, pixel.V(1, 0), 1.14, pixel.V(9.43, 0)},
+	} {
+		contact, ok := collide(check.a, check.b)
+		if ok != (check.penetration > 0) {
+			t.Errorf("%s: collided is %v", check.name, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if contact.Normal.Sub(check.normal).Len() > 1e-9 || math.Abs(contact.Penetration-check.penetration) > 0.01 {
+			t.Errorf("%s: normal %v and penetration %v, expected %v and %v", check.name, contact.Normal, contact.Penetration, check.normal, check.penetration)
+		}
+		if contact.Point.Sub(check.point).Len() > 0.01 {
+			t.Errorf("%s: touching at %v, expected %v", check.name, contact.Point, check.point)
+		}
+	}
+}
+
+func TestCollisionSystem(t *testing.T) {
+	big := circle(0, 0, 200) // takes up lots of cells
+	ship := circle(150, 150, 100)
+	projectile := box(-190, 0, 4, 4, 0)
+	projectile.layer, projectile.mask = CollisionProjectiles, CollisionShips
+	other := box(-190, 0, 4, 4, 0) // on top of the projectile
+	other.layer, other.mask = CollisionProjectiles, CollisionShips
+	far := circle(10000, 0, 10)
+
+	cs := NewCollisionSystem(64)
+	for i := 0; i < 3; i++ {
+		contacts := cs.Detect([]Collider{big, ship, far, projectile, other})
+
+		var pairs []string
+		for _, contact := range contacts {
+			pairs = append(pairs, fmt.Sprintf("%d-%d", contact.i, contact.j))
+		}
+		// every pair only once and in order, projectiles don't hit each other
+		if fmt.Sprint(pairs) != "[0-1 0-3 0-4]" {
+			t.Errorf("detect %d: contacts %v", i, pairs)
+		}
+		if contacts[0].A != big || contacts[0].B != ship {
+			t.Errorf("detect %d: the first contact is between %v and %v", i, contacts[0].A, contacts[0].B)
+		}
+	}
+}
+
+func benchmarkCollisions(b *testing.B, count int) {
+	rng := rand.New(rand.NewSource(1))
+	// about as crowded as a busy asteroid field
+	side := math.Sqrt(float64(count)) * 100
+	colliders := make([]Collider, count)
+	for i := range colliders {
+		x, y := rng.Float64()*side, rng.Float64()*side
+		if i%2 == 0 {
+			colliders[i] = circle(x, y, 5+rng.Float64()*20)
+		} else {
+			colliders[i] = box(x, y, 10+rng.Float64()*30, 10+rng.Float64()*30, rng.Float64()*math.Pi)
+		}
+	}
+
+	cs := NewCollisionSystem(64)
+	cs.Detect(colliders)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cs.Detect(colliders)
+	}
+}
+
+func BenchmarkCollisions1k(b *testing.B)  { benchmarkCollisions(b, 1000) }
+func BenchmarkCollisions5k(b *testing.B)  { benchmarkCollisions(b, 5000) }
+func BenchmarkCollisions20k(b *testing.B) { benchmarkCollisions(b, 20000) }
+
+func TestResolveShips(t *testing.T) {
+	a, b := NewShip("A"), NewShip("B")
+	b.Translate(pixel.V(30, 0))
+	a.velocity, b.velocity = pixel.V(100, 0), pixel.V(-100, 0)
+
+	contacts := NewCollisionSystem(64).Detect([]Collider{a, b})
+	if len(contacts) != 1 {
+		t.Fatalf("%d contacts between overlapping ships", len(contacts))
+	}
+	energy := resolve(contacts[0])
+
+	// they bounce apart with half the speed, and keep their momentum
+	if a.velocity.Sub(pixel.V(-50, 0)).Len() > 1e-9 || b.velocity.Sub(pixel.V(50, 0)).Len() > 1e-9 {
+		t.Errorf("velocities %v and %v after the bounce", a.velocity, b.velocity)
+	}
+	if gap := b.Coordinates().X - a.Coordinates().X; math.Abs(gap-32) > 1e-9 {
+		t.Errorf("the ships are %v apart after being pushed apart", gap)
+	}
+	// ½μv² with μ = m/2 and v = 200, a quarter of it bounced back
+	if expected := 0.5 * a.Mass() / 2 * 200 * 200 * 0.75; math.Abs(energy-expected) > 1e-6 {
+		t.Errorf("the impact had %v energy, expected %v", energy, expected)
+	}
+
+	// moving apart already, they're only pushed apart
+	b.Translate(pixel.V(-2, 0))
+	contacts = NewCollisionSystem(64).Detect([]Collider{a, b})
+	if energy := resolve(contacts[0]); energy != 0 || a.velocity.X != -50 {
+		t.Errorf("separating ships bounced with %v energy", energy)
+	}
+}
+
+func TestCrashIntoCelestial(t *testing.T) {
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vera := system.Celestials()[0]
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(system, player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+
+	ship := player.Ship()
+	ship.Translate(vera.Coordinates().Add(pixel.V(0, -200)))
+	ship.velocity = pixel.V(0, 400)
+	for i := 0; i < 60; i++ {
+		scene.tick(1.0 / 60)
+		// the ship's corners can't be much farther in than it moves in a step
+		if distance := ship.Coordinates().Sub(vera.Coordinates()).Len(); distance < vera.Radius()+16-400*physicsStep {
+			t.Fatalf("tick %d: the ship flew into the planet, %v from its center", i, distance)
+		}
+	}
+
+	if ship.Velocity().Y >= 0 {
+		t.Errorf("the ship is still going %v toward the planet", ship.Velocity())
+	}
+	// the shields take the crash, or the hull when there aren't enough
+	shields, maxShields := ship.Shields()
+	if hull, max := ship.Hull(); hull >= max && shields >= maxShields {
+		t.Errorf("the ship crashed into a planet and has %v of %v hull and %v of %v shields left", hull, max, shields, maxShields)
+	}
+	if vera.Coordinates() != pixel.V(300, 200) {
+		t.Errorf("the planet was pushed to %v", vera.Coordinates())
+	}
+}
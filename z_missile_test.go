@@ -0,0 +1,188 @@
+package spacegame
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// a scene with the player's ship at the origin, and a target to shoot at
+func missileRange(t *testing.T, target pixel.Vec) (*SpaceScene, *Ship, *Ship, *ShipLauncher) {
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Range"), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+	ship := player.Ship()
+	enemy := NewShip("Target")
+	enemy.Translate(target)
+	scene.entities = append(scene.entities, enemy)
+
+	scene.tick(1.0 / 60)
+	ship.systems["scanner"].(*ShipScanner).NextTarget()
+	return scene, ship, enemy, ship.systems["launcher"].(*ShipLauncher)
+}
+
+// tick the scene until the launcher has a lock, returns the seconds it took
+func acquireLock(scene *SpaceScene, launcher *ShipLauncher) float64 {
+	for i := 0; i < 60*30; i++ {
+		if launcher.Locked() != nil {
+			return float64(i) / 60
+		}
+		scene.tick(1.0 / 60)
+	}
+	return math.Inf(1)
+}
+
+func TestTargetLock(t *testing.T) {
+	scene, ship, _, launcher := missileRange(t, pixel.V(0, 500))
+	near := acquireLock(scene, launcher)
+	expected := launcher.LockTime * 1.5
+	if math.Abs(near-expected) > 2.0/60 {
+		t.Errorf("the lock took %v, expected %v", near, expected)
+	}
+
+	// farther away, and with a worse scanner, it takes longer
+	scene, ship, _, launcher = missileRange(t, pixel.V(0, 1500))
+	ship.systems["scanner"].(*ShipScanner).Accuracy = 50
+	if far := acquireLock(scene, launcher); far < near*3 {
+		t.Errorf("a far lock with half the accuracy took %v, a near one %v", far, near)
+	}
+
+	// a new target starts over, none at all means no lock
+	scanner := ship.systems["scanner"].(*ShipScanner)
+	scanner.ClearTarget()
+	scene.tick(1.0 / 60)
+	if launcher.Locked() != nil || launcher.lock != 0 {
+		t.Errorf("locked on %v without a target", launcher.Locked())
+	}
+
+	// out of the scanner's range nothing locks
+	scanner.Range = 1000
+	scanner.NextTarget()
+	if lock := acquireLock(scene, launcher); !math.IsInf(lock, 1) {
+		t.Errorf("locked on a target out of range after %v", lock)
+	}
+}
+
+func TestMissileHoming(t *testing.T) {
+	scene, ship, enemy, launcher := missileRange(t, pixel.V(600, 400))
+	enemy.velocity = pixel.V(0, 80)
+
+	// launching without a lock does nothing
+	ammo := launcher.Ammo
+	ship.Process(pilotAction{actionLaunch, 1.0 / 60})
+	scene.tick(1.0 / 60)
+	if launcher.Ammo != ammo || scene.projectiles.Count() != 0 {
+		t.Fatal("launched without a lock")
+	}
+
+	acquireLock(scene, launcher)
+	ship.Process(pilotAction{actionLaunch, 1.0 / 60})
+	scene.tick(1.0 / 60)
+	if launcher.Ammo != ammo-1 || scene.projectiles.Count() != 1 {
+		t.Fatalf("%d of %d missiles left, %d in flight", launcher.Ammo, ammo, scene.projectiles.Count())
+	}
+
+	missile := scene.projectiles.Projectiles()[0]
+	heading := missile.Velocity().Angle()
+	for i := 0; i < 60*5 && scene.projectiles.Count() > 0; i++ {
+		scene.tick(1.0 / 60)
+		// it can't turn faster than its turn rate
+		if turned := math.Abs(normalizeAngle(missile.Velocity().Angle() - heading)); turned > launcher.TurnRate/60+1e-6 && !missile.dead {
+			t.Fatalf("the missile turned %v in a frame", turned)
+		}
+		heading = missile.Velocity().Angle()
+	}
+	if hull, max := enemy.Hull(); max-hull != launcher.Damage {
+		t.Errorf("the target took %v damage from a missile doing %v", max-hull, launcher.Damage)
+	}
+}
+
+func TestMissileFuel(t *testing.T) {
+	pool := NewProjectilePool(4)
+	target := NewShip("Target")
+	target.Translate(pixel.V(-1000, 0))
+	pool.Fire(Shot{
+		Position: pixel.ZV,
+		Velocity: pixel.V(0, 100),
+		Lifetime: 10,
+		Guidance: &Guidance{Target: target, Thrust: 200, MaxSpeed: 300, TurnRate: 1, Fuel: 2},
+	})
+	missile := pool.Projectiles()[0]
+	for i := 0; i < 120; i++ {
+		pool.Step(1.0 / 60)
+	}
+	if speed := missile.Velocity().Len(); math.Abs(speed-300) > 1e-6 {
+		t.Errorf("the missile is going %v on its top speed of 300", speed)
+	}
+	// out of fuel it flies straight on
+	for i := 0; i < 10; i++ {
+		pool.Step(1.0 / 60)
+	}
+	velocity := missile.Velocity()
+	for i := 0; i < 60; i++ {
+		pool.Step(1.0 / 60)
+	}
+	if missile.Velocity() != velocity || missile.Burning() {
+		t.Errorf("the missile went from %v to %v without fuel", velocity, missile.Velocity())
+	}
+}
+
+func TestMissileCountermeasures(t *testing.T) {
+	// a decoy between the missile and its target lures it away
+	scene, ship, enemy, launcher := missileRange(t, pixel.V(0, 800))
+	enemyLauncher := &ShipLauncher{Decoys: 1}
+	enemy.systems["launcher"] = enemyLauncher
+	enemyLauncher.Install(enemy)
+	// facing away from the missile, the decoy goes out toward it
+
+	acquireLock(scene, launcher)
+	ship.Process(pilotAction{actionLaunch, 1.0 / 60})
+	scene.tick(1.0 / 60)
+	enemy.Process(pilotAction{actionDecoy, 1.0 / 60})
+	for i := 0; i < 60*4; i++ {
+		scene.tick(1.0 / 60)
+	}
+	if hull, max := enemy.Hull(); hull != max || enemyLauncher.Decoys != 0 {
+		t.Errorf("the missile went through the decoy, the target has %v of %v hull left", hull, max)
+	}
+
+	// and a missile can be shot down
+	scene, ship, enemy, launcher = missileRange(t, pixel.V(0, 800))
+	enemy.angle = math.Pi
+	testWeapon(enemy, &ShipWeapon{Damage: 5, FireRate: 10, ProjectileSpeed: 900, Lifetime: 1.5})
+	acquireLock(scene, launcher)
+	ship.Process(pilotAction{actionLaunch, 1.0 / 60})
+	for i := 0; i < 60*3; i++ {
+		enemy.Process(pilotAction{actionFire, 1.0 / 60})
+		scene.tick(1.0 / 60)
+	}
+	if hull, max := enemy.Hull(); hull != max {
+		t.Errorf("the missile wasn't shot down, the target has %v of %v hull left", hull, max)
+	}
+	if hull, max := ship.Hull(); hull == max {
+		t.Error("the target's guns never reached the ship")
+	}
+}
+
+func TestLauncherAmmoIsSaved(t *testing.T) {
+	scene, ship, _, launcher := missileRange(t, pixel.V(0, 500))
+	acquireLock(scene, launcher)
+	ship.Process(pilotAction{actionLaunch, 1.0 / 60})
+	ship.Process(pilotAction{actionDecoy, 1.0 / 60})
+	scene.tick(1.0 / 60)
+
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(ship.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	config := SerializableShip{Systems: ShipSystems{}}
+	if err := json.NewDecoder(&buffer).Decode(&config); err != nil {
+		t.Fatal(err)
+	}
+	loaded := config.load().systems["launcher"].(*ShipLauncher)
+	if loaded.Ammo != launcher.Ammo || loaded.Decoys != launcher.Decoys || loaded.Ammo != 7 {
+		t.Errorf("the launcher was saved with %d missiles and %d decoys, it had %d and %d", loaded.Ammo, loaded.Decoys, launcher.Ammo, launcher.Decoys)
+	}
+}
@@ -0,0 +1,156 @@
+package spacegame
+
+import (
+	"image/color"
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+
+	"github.com/faiface/pixel"
+)
+
+// The particle system only needs emitters from the resource manager
+type testEmitters map[string]*EmitterConfig
+
+func (te testEmitters) CreateResource(renderable Entity, path string) {}
+func (te testEmitters) Find(search string) []Resource                 { return nil }
+func (te testEmitters) FindInCollection(collection string) []Resource { return nil }
+func (te testEmitters) ImportDefault()                                {}
+func (te testEmitters) Resource(renderable Entity) *Resource          { return nil }
+func (te testEmitters) Emitter(name string) (*EmitterConfig, error) {
+	return te[name], nil
+}
+func (te testEmitters) Font(name string, size float64) (font.Face, error) {
+	return nil, nil
+}
+
+var testSpark = &EmitterConfig{
+	Rate:        100,
+	Burst:       10,
+	LifetimeMin: 1,
+	LifetimeMax: 1,
+	SpeedMin:    10,
+	SpeedMax:    10,
+	Colors:      []ColorKey{{0, 255, 255, 255, 255}, {1, 255, 0, 0, 0}},
+	Sizes:       []SizeKey{{0, 4}, {0.5, 2}, {1, 2}},
+}
+
+func TestParticleSystem(t *testing.T) {
+	ps := NewParticleSystem(testEmitters{"spark": testSpark}, 25, 1)
+
+	ps.Emit("spark", pixel.ZV, pixel.ZV, 0, 0.1)
+	if ps.Count() != 10 {
+		t.Errorf("%d particles after emitting for 0.1s at 100/s", ps.Count())
+	}
+
+	// the pool doesn't grow
+	ps.Burst("spark", pixel.V(100, 0), pixel.V(5, 0))
+	ps.Burst("spark", pixel.V(100, 0), pixel.V(5, 0))
+	if ps.Count() != 25 {
+		t.Errorf("%d particles in a pool of 25", ps.Count())
+	}
+
+	ps.Update(0.5)
+	for _, p := range ps.particles[:ps.Count()] {
+		if moved := p.position.Sub(pixel.V(100, 0)).Len(); p.position.X >= 50 && moved == 0 {
+			t.Errorf("particle at %v did not move", p.position)
+		}
+	}
+	ps.Update(0.6)
+	if ps.Count() != 0 {
+		t.Errorf("%d particles outlived their lifetime", ps.Count())
+	}
+
+	ps.Emit("missing", pixel.ZV, pixel.ZV, 0, 1)
+	if ps.Count() != 0 {
+		t.Error("emitted particles from an emitter that doesn't exist")
+	}
+}
+
+func TestEmitterCurves(t *testing.T) {
+	if c := testSpark.color(0.5); c != (color.RGBA{128, 64, 64, 128}) {
+		t.Errorf("color halfway is %v", c)
+	}
+	for _, check := range []struct{ t, size float64 }{{0, 4}, {0.25, 3}, {0.75, 2}, {2, 2}} {
+		if size := testSpark.size(check.t); size != check.size {
+			t.Errorf("size at %v is %v, expected %v", check.t, size, check.size)
+		}
+	}
+}
+
+func TestParticlesGolden(t *testing.T) {
+	resourceManager := loadTestResources()
+	renderer := NewImageRenderer(320, 240, resourceManager)
+	camera := NewChaseCamera(NewShip("Starbridge"))
+	camera.Update(0)
+
+	ps := NewParticleSystem(resourceManager, 1024, 1)
+	for i := 0; i < 30; i++ {
+		if i == 10 {
+			ps.Burst("explosion", pixel.V(-60, 0), pixel.ZV)
+			ps.Burst("debris", pixel.V(-60, 0), pixel.ZV)
+		}
+		// a plume going down and to the left
+		ps.Emit("exhaust", pixel.V(80, 60), pixel.ZV, 3*math.Pi/4, 1.0/60)
+		ps.Update(1.0 / 60)
+	}
+
+	queue := NewRenderQueue(renderer)
+	ps.Submit(queue, camera)
+	queue.Flush()
+
+	compareGolden(t, "particles", renderer.Image())
+}
+
+// A frame is 1/60 of a second, ticking 50k particles should take a fraction of that
+func BenchmarkParticles50k(b *testing.B) {
+	const count, budget = 50000, time.Second / 60 / 4
+
+	ps := NewParticleSystem(testEmitters{"spark": testSpark}, count, 1)
+	config := *testSpark
+	config.LifetimeMin, config.LifetimeMax = 1e9, 1e9 // they must all stay alive
+	ps.configs["spark"] = &config
+	for ps.Count() < count {
+		ps.Burst("spark", pixel.ZV, pixel.ZV)
+	}
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		ps.Update(1.0 / 60)
+	}
+	perTick := time.Since(start) / time.Duration(b.N)
+
+	b.ReportMetric(float64(perTick)/float64(time.Second/60), "frames/op")
+	if perTick > budget {
+		b.Errorf("ticking %d particles took %v, the budget is %v", count, perTick, budget)
+	}
+}
+
+func TestEnginePlume(t *testing.T) {
+	ps := NewParticleSystem(testEmitters{"exhaust": testSpark}, 256, testSeed)
+	ship := NewShip("Starbridge")
+
+	// the pilot's thrust comes out the back of the ship, which points up
+	for i := 0; i < 30; i++ {
+		ship.Process(pilotAction{actionAccel, 1.0 / 60})
+		ship.Update(SceneInformation{Particles: ps, Elapsed: 1.0 / 60})
+	}
+	if count := ps.Count(); count < 45 || count > 55 {
+		t.Fatalf("%d particles after half a second of thrust, at 100 a second", count)
+	}
+	for _, p := range ps.particles[:ps.alive] {
+		if p.position.Y >= 0 || p.velocity.Y >= 0 {
+			t.Errorf("a particle at %v going %v, not out the back", p.position, p.velocity)
+		}
+	}
+
+	// and stops when the pilot lets go
+	ps.Update(2)
+	ship.Update(SceneInformation{Particles: ps, Elapsed: 1.0 / 60})
+	if ps.Count() != 0 {
+		t.Errorf("%d particles without any thrust", ps.Count())
+	}
+}
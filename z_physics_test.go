@@ -0,0 +1,381 @@
+package spacegame
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+var timesteps = []float64{1.0 / 30, 1.0 / 60, physicsStep, 1.0 / 1000}
+
+// simulate steps b for duration seconds, the force is applied before every step
+func simulate(b *body, force pixel.Vec, torque, duration, dt float64) {
+	for elapsed := 0.0; elapsed < duration-dt/2; elapsed += dt {
+		b.ApplyForce(force)
+		b.ApplyTorque(torque)
+		b.Step(dt)
+	}
+}
+
+func TestBodyKinematics(t *testing.T) {
+	const duration = 2.0
+	for _, dt := range timesteps {
+		// v = v0 + at, x = x0 + v0t + at²/2
+		b := &body{coordinates: pixel.V(10, -10), velocity: pixel.V(5, 0), mass: 4, inertia: 8}
+		simulate(b, pixel.V(0, 20), 0, duration, dt)
+
+		acceleration := 20.0 / 4
+		if v := b.velocity; math.Abs(v.X-5) > 1e-9 || math.Abs(v.Y-acceleration*duration) > 1e-9 {
+			t.Errorf("dt %v: velocity is %v after %vs", dt, v, duration)
+		}
+		expected := pixel.V(10+5*duration, -10+acceleration*duration*duration/2)
+		// semi-implicit Euler gets ahead by at·dt/2
+		tolerance := acceleration*duration*dt/2 + 1e-9
+		if offset := b.coordinates.Sub(expected); math.Abs(offset.X) > 1e-9 || math.Abs(offset.Y) > tolerance {
+			t.Errorf("dt %v: position is %v, expected %v", dt, b.coordinates, expected)
+		}
+
+		// ω = τt/I
+		spinning := &body{mass: 4, inertia: 8}
+		simulate(spinning, pixel.ZV, 2, duration, dt)
+		if math.Abs(spinning.angularVelocity-2.0/8*duration) > 1e-9 {
+			t.Errorf("dt %v: spinning at %v after %vs", dt, spinning.angularVelocity, duration)
+		}
+	}
+}
+
+func TestBodyImpulse(t *testing.T) {
+	b := &body{mass: 2, inertia: 4}
+	b.ApplyImpulse(pixel.V(6, 0))
+	b.ApplyAngularImpulse(2)
+	if b.velocity != pixel.V(3, 0) || b.angularVelocity != 0.5 {
+		t.Errorf("velocity %v and spin %v after the impulses", b.velocity, b.angularVelocity)
+	}
+
+	// forces only last one step
+	b.ApplyForce(pixel.V(100, 0))
+	b.Step(0.1)
+	b.Step(0.1)
+	if math.Abs(b.velocity.X-8) > 1e-9 {
+		t.Errorf("velocity is %v after a force acted for one step", b.velocity)
+	}
+}
+
+func TestShipThrustToWeight(t *testing.T) {
+	light, heavy := NewShip("Light"), NewShip("Heavy")
+	heavy.hullMass *= 3
+	heavy.updateMass()
+	if heavy.Mass() <= light.Mass() {
+		t.Fatalf("%v tonnes is not heavier than %v", heavy.Mass(), light.Mass())
+	}
+
+	for _, dt := range timesteps {
+		speeds := make([]float64, 2)
+		for i, ship := range []*Ship{light, heavy} {
+			ship.velocity = pixel.ZV
+			engine := ship.systems["engine"].(*ShipEngine)
+			engine.MaxVel = math.Inf(1)
+			simulateEngine(ship, dt, 0.5, func() { engine.Accelerate(dt) })
+			speeds[i] = ship.Velocity().Len()
+
+			// v = Ft/m
+			if expected := engine.Thrust * 0.5 / ship.Mass(); math.Abs(speeds[i]-expected) > 1e-6 {
+				t.Errorf("dt %v: %s is going %v after thrusting for 0.5s, expected %v", dt, ship.Name(), speeds[i], expected)
+			}
+		}
+		if speeds[1] >= speeds[0] {
+			t.Errorf("dt %v: the heavy ship went %v, the light one %v", dt, speeds[1], speeds[0])
+		}
+	}
+}
+
+func TestEngineForces(t *testing.T) {
+	ship := NewShip("Starbridge")
+	engine := ship.systems["engine"].(*ShipEngine)
+	engine.MaxVel = math.Inf(1)
+
+	// the pilot's thrust waits for the physics, which push the ship with a force for each step
+	engine.Accelerate(2 * physicsStep)
+	engine.Turn(2 * physicsStep)
+	if ship.Velocity() != pixel.ZV || ship.angularVelocity != 0 {
+		t.Fatalf("going %v spinning %v before a physics step", ship.Velocity(), ship.angularVelocity)
+	}
+	ship.Step(physicsStep)
+	if expected := engine.Acceleration() * physicsStep; math.Abs(ship.Velocity().Y-expected) > 1e-9 {
+		t.Errorf("going %v after a step of thrust, expected %v", ship.Velocity(), expected)
+	}
+	if ship.force != pixel.ZV || ship.torque != 0 {
+		t.Errorf("%v and %v are still acting after the step", ship.force, ship.torque)
+	}
+	ship.Step(physicsStep)
+	ship.Step(physicsStep)
+	// the ship turned a little in between
+	if expected := engine.Acceleration() * 2 * physicsStep; math.Abs(ship.Velocity().Len()-expected) > 1e-6 {
+		t.Errorf("going %v after thrusting for two steps, expected %v", ship.Velocity(), expected)
+	}
+	if expected := engine.AngularAcceleration() * 2 * physicsStep; math.Abs(ship.angularVelocity-expected) > 1e-9 {
+		t.Errorf("spinning %v after turning for two steps, expected %v", ship.angularVelocity, expected)
+	}
+}
+
+func TestShipTurning(t *testing.T) {
+	for _, dt := range timesteps {
+		ship := NewShip("Starbridge")
+		engine := ship.systems["engine"].(*ShipEngine)
+
+		// the thrusters can't spin the ship faster than its turn speed
+		simulateEngine(ship, dt, 2, func() { engine.Turn(dt) })
+		if math.Abs(ship.angularVelocity-engine.TurnSpeed) > 1e-9 {
+			t.Errorf("dt %v: spinning at %v, the turn speed is %v", dt, ship.angularVelocity, engine.TurnSpeed)
+		}
+
+		// they stop it again when nobody is turning
+		simulateEngine(ship, dt, 2, func() {})
+		if math.Abs(ship.angularVelocity) > 1e-9 {
+			t.Errorf("dt %v: still spinning at %v", dt, ship.angularVelocity)
+		}
+
+		// and align it to face right without overshooting for long, ships point up at angle 0
+		simulateEngine(ship, dt, 3, func() { engine.align(0, dt) })
+		if delta := normalizeAngle(ship.Angle() + math.Pi/2); math.Abs(delta) > 0.05 {
+			t.Errorf("dt %v: aligned to %v", dt, ship.Angle())
+		}
+	}
+}
+
+// simulateEngine runs a ship the way the scene does, with the pilot's commands first
+func simulateEngine(ship *Ship, dt, duration float64, pilot func()) {
+	var lag float64
+	for elapsed := 0.0; elapsed < duration-dt/2; elapsed += dt {
+		pilot()
+		ship.Update(SceneInformation{Elapsed: dt})
+		for lag += dt; lag >= physicsStep-1e-12; lag -= physicsStep {
+			ship.Step(physicsStep)
+		}
+	}
+}
+
+func TestFixedTimestep(t *testing.T) {
+	// however fast the frames are, the ship ends up in the same place
+	var positions []pixel.Vec
+	for _, frames := range []int{30, 60, 144} {
+		system := NewSolarSystem("Empty") // nothing pulls on the ship
+		player := NewPlayer("Test", loadTestResources())
+		scene := NewSpaceScene(system, player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+		player.Ship().velocity = pixel.V(120, 0)
+		for i := 0; i < frames; i++ {
+			scene.tick(1.0 / float64(frames))
+		}
+		positions = append(positions, player.Ship().Coordinates())
+	}
+	for _, position := range positions {
+		// a step is at most left over
+		if math.Abs(position.X-120) > 120*physicsStep+1e-9 || position.Y != 0 {
+			t.Errorf("the ship went to %v in a second, not (120, 0)", positions)
+		}
+	}
+}
+
+func TestGravity(t *testing.T) {
+	well := CelestialConfig{Radius: 50, Mass: 1000, Influence: 2000}.load().(GravityWell)
+
+	near, far := pull(well, pixel.V(0, -200)), pull(well, pixel.V(0, -400))
+	if math.Abs(near.Y-gravity*1000.0/(200*200)) > 1e-9 || near.X != 0 {
+		t.Errorf("pulled %v from 200 away", near)
+	}
+	if math.Abs(near.Y/far.Y-4) > 1e-9 {
+		t.Errorf("twice as far the pull goes from %v to %v", near, far)
+	}
+
+	// near the surface it stops getting stronger
+	capped := pull(well, pixel.V(0, -50*surfaceCap))
+	if surface := pull(well, pixel.V(0, -50)); surface != capped {
+		t.Errorf("pulled %v at the surface and %v a bit farther out", surface, capped)
+	}
+	// and far away it fades out
+	if edge := pull(well, pixel.V(1990, 0)); edge.X >= 0 || -edge.X > gravity*1000.0/(1990*1990)/5 {
+		t.Errorf("pulled %v near the edge of the influence", edge)
+	}
+	if outside := pull(well, pixel.V(2000, 0)); outside != pixel.ZV {
+		t.Errorf("pulled %v outside the influence", outside)
+	}
+
+	weightless := CelestialConfig{Radius: 50}.load().(GravityWell)
+	if g := gravityAt([]Celestial{weightless.(Celestial)}, pixel.V(100, 0)); g != pixel.ZV {
+		t.Errorf("a celestial without mass pulls %v", g)
+	}
+}
+
+func TestOrbit(t *testing.T) {
+	orbit := func() []pixel.Vec {
+		planet := CelestialConfig{Name: "Planet", Radius: 50, Mass: 6000}.load()
+		player := NewPlayer("Test", loadTestResources())
+		scene := NewSpaceScene(NewSolarSystem("Orbit", planet), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+
+		// fast enough to go round in a circle
+		const radius = 300
+		ship := player.Ship()
+		ship.Translate(pixel.V(radius, 0))
+		ship.velocity = pixel.V(0, math.Sqrt(gravity*6000/radius))
+
+		var path []pixel.Vec
+		period := 2 * math.Pi * radius / ship.velocity.Len()
+		for i := 0; i < int(period*60); i++ {
+			scene.tick(1.0 / 60)
+			path = append(path, ship.Coordinates())
+			if distance := ship.Coordinates().Len(); math.Abs(distance-radius) > radius*0.01 {
+				t.Fatalf("tick %d: the ship went %v from the planet in a circular orbit", i, distance)
+			}
+		}
+		if back := path[len(path)-1].Sub(pixel.V(radius, 0)).Len(); back > 10 {
+			t.Errorf("the ship came back %v from where it started", back)
+		}
+		return path
+	}
+
+	a, b := orbit(), orbit()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("tick %d: the ship was at %v and %v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestKeplerOrbit(t *testing.T) {
+	orbit := &OrbitConfig{SemiMajorAxis: 100, Eccentricity: 0.5, Period: 60, Periapsis: math.Pi / 2}
+
+	// closest at time 0, farthest half way round
+	if position, _ := orbit.At(0); position.Sub(pixel.V(0, 50)).Len() > 1e-9 {
+		t.Errorf("periapsis at %v", position)
+	}
+	if position, _ := orbit.At(30); position.Sub(pixel.V(0, -150)).Len() > 1e-9 {
+		t.Errorf("apoapsis at %v", position)
+	}
+	if position, _ := orbit.At(60); position.Sub(pixel.V(0, 50)).Len() > 1e-9 {
+		t.Errorf("back at %v after a period", position)
+	}
+
+	// the velocity is how fast the position changes
+	for _, time := range []float64{0, 7, 22.5, 41} {
+		position, velocity := orbit.At(time)
+		later, _ := orbit.At(time + 1e-6)
+		if estimate := later.Sub(position).Scaled(1e6); estimate.Sub(velocity).Len() > 1e-3 {
+			t.Errorf("at %v the velocity is %v, but it moves at %v", time, velocity, estimate)
+		}
+	}
+}
+
+func TestMoons(t *testing.T) {
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	planet, moon := system.Celestials()[0], system.Celestials()[1]
+
+	// the moon goes round the planet wherever the planet is
+	planet.Translate(pixel.V(1000, 0))
+	system.SetTime(123.4)
+	orbit := moon.(celestialBase).base().orbit
+	offset, velocity := orbit.At(123.4)
+	if moon.Coordinates() != planet.Coordinates().Add(offset) || moon.Velocity() != velocity {
+		t.Errorf("the moon is at %v going %v around the planet at %v", moon.Coordinates(), moon.Velocity(), planet.Coordinates())
+	}
+
+	// saved and loaded, everything is exactly where it was
+	path := "/tmp/vera_later.json"
+	if err := system.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSystem(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, celestial := range loaded.Celestials() {
+		if was := system.Celestials()[i]; celestial.Coordinates() != was.Coordinates() || celestial.Velocity() != was.Velocity() {
+			t.Errorf("%s was at %v going %v, and loaded at %v going %v", celestial.Name(), was.Coordinates(), was.Velocity(), celestial.Coordinates(), celestial.Velocity())
+		}
+	}
+
+	// moons that orbit each other can't go anywhere
+	a := CelestialConfig{Name: "A", Orbit: &OrbitConfig{Parent: "B", SemiMajorAxis: 10, Period: 1}}.load()
+	b := CelestialConfig{Name: "B", Orbit: &OrbitConfig{Parent: "A", SemiMajorAxis: 10, Period: 1}}.load()
+	NewSolarSystem("Circular", a, b).SetTime(0.5)
+	if math.Abs(a.Coordinates().Sub(b.Coordinates()).Len()-10) > 1e-9 {
+		t.Errorf("%v and %v aren't on their orbits", a.Coordinates(), b.Coordinates())
+	}
+}
+
+// velocities for the flight assist tests, in every direction and under the top speed
+func arbitraryVelocities(count int) []pixel.Vec {
+	rng := rand.New(rand.NewSource(45))
+	velocities := make([]pixel.Vec, count)
+	for i := range velocities {
+		velocities[i] = pixel.V(rng.Float64()*600-300, rng.Float64()*600-300)
+	}
+	return velocities
+}
+
+func TestReverse(t *testing.T) {
+	for _, velocity := range arbitraryVelocities(8) {
+		ship := NewShip("Starbridge")
+		ship.velocity = velocity
+		engine := ship.systems["engine"].(*ShipEngine)
+
+		simulateEngine(ship, 1.0/60, 3, func() { engine.reverse(1.0 / 60) })
+		// ships point up at angle 0
+		facing := pixel.V(0, 1).Rotated(ship.Angle())
+		if retrograde := ship.Velocity().Unit().Scaled(-1); facing.Dot(retrograde) < 0.99 {
+			t.Errorf("going %v the ship faces %v after reversing", ship.Velocity(), facing)
+		}
+	}
+}
+
+func TestFlightAssist(t *testing.T) {
+	for _, velocity := range arbitraryVelocities(8) {
+		for _, assist := range []FlightAssist{AssistNewtonian, AssistDrift, AssistFullStop} {
+			ship := NewShip("Starbridge")
+			ship.velocity = velocity
+			engine := ship.systems["engine"].(*ShipEngine)
+			engine.SetAssist(assist)
+			engine.MaxVel = 1000 // so the top speed doesn't get in the way
+
+			// thrust straight up for a bit
+			simulateEngine(ship, 1.0/60, 0.25, func() { engine.Accelerate(1.0 / 60) })
+			drift := ship.Velocity().X
+			switch assist {
+			case AssistNewtonian, AssistFullStop:
+				if math.Abs(drift-velocity.X) > 1e-9 {
+					t.Errorf("%v from %v: drifting %v after thrusting", assist, velocity, drift)
+				}
+			case AssistDrift:
+				expected := math.Max(0, math.Abs(velocity.X)-engine.Acceleration()*driftThrust*0.25)
+				if math.Abs(math.Abs(drift)-expected) > 1e-6 {
+					t.Errorf("%v from %v: drifting %v after thrusting, expected %v", assist, velocity, drift, expected)
+				}
+			}
+
+			// and let go for a while
+			before := ship.Velocity()
+			simulateEngine(ship, 1.0/60, 10, func() {})
+			if assist == AssistFullStop {
+				if speed := ship.Velocity().Len(); speed > speedTolerance {
+					t.Errorf("%v from %v: still going %v after letting go", assist, velocity, speed)
+				}
+			} else if ship.Velocity() != before {
+				t.Errorf("%v from %v: going %v after letting go, was %v", assist, velocity, ship.Velocity(), before)
+			}
+		}
+	}
+
+	// the pilot's hands on the controls win
+	ship := NewShip("Starbridge")
+	ship.velocity = pixel.V(100, 0)
+	engine := ship.systems["engine"].(*ShipEngine)
+	engine.SetAssist(AssistFullStop)
+	simulateEngine(ship, 1.0/60, 2, func() { engine.Turn(1.0 / 60) })
+	if ship.Velocity() != pixel.V(100, 0) {
+		t.Errorf("the ship braked to %v while the pilot was turning it", ship.Velocity())
+	}
+}
@@ -0,0 +1,506 @@
+package spacegame
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+
+	"github.com/faiface/pixel"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden images in testdata/golden")
+
+// compareGolden compares img to testdata/golden/<name>.png
+// Run the tests with -update to accept the current output.
+func compareGolden(t *testing.T, name string, img *image.RGBA) {
+	path := filepath.Join("testdata", "golden", name+".png")
+
+	if *updateGolden {
+		if err := savePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("missing golden image (run with -update to create it): %v", err)
+	}
+	defer file.Close()
+	golden, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("%s: size %v, golden image is %v", name, img.Bounds(), golden.Bounds())
+	}
+
+	// allow tiny rounding differences between platforms
+	const tolerance = 8
+	mismatched := 0
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := img.At(x, y).RGBA()
+			r2, g2, b2, a2 := golden.At(x, y).RGBA()
+			if diff(r1, r2) > tolerance || diff(g1, g2) > tolerance || diff(b1, b2) > tolerance || diff(a1, a2) > tolerance {
+				mismatched++
+			}
+		}
+	}
+
+	if mismatched > 0 {
+		actual := filepath.Join(os.TempDir(), name+"_actual.png")
+		savePNG(actual, img)
+		t.Errorf("%s: %d pixels differ from the golden image, output saved to %s", name, mismatched, actual)
+	}
+}
+
+// diff of two 16-bit color channels, in 8-bit units
+func diff(a, b uint32) uint32 {
+	if a > b {
+		return (a - b) >> 8
+	}
+	return (b - a) >> 8
+}
+
+// Scenes in the tests are all seeded the same, so they play out the same every time
+const testSeed = 1
+
+func loadTestResources() *StandardResourceManager {
+	resourceManager := NewStandardResourceManager("data/resources")
+	resourceManager.ImportDefault()
+	return resourceManager
+}
+
+func TestImageRendererSprite(t *testing.T) {
+	resourceManager := loadTestResources()
+	renderer := NewImageRenderer(64, 64, resourceManager)
+
+	ship := NewShip("Starbridge")
+	renderer.Render(ship, renderer.Center(), 1)
+
+	// the ship's sprite covers the middle of the image, but not the corners
+	img := renderer.Image()
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0xffff {
+		t.Errorf("expected the cleared background in the corner")
+	}
+	empty := true
+	for y := 16; y < 48; y++ {
+		for x := 16; x < 48; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r+g+b != 0 {
+				empty = false
+			}
+		}
+	}
+	if empty {
+		t.Errorf("ship was not rendered")
+	}
+
+	// entirely off-screen entities are culled
+	renderer.Clear()
+	renderer.Render(ship, pixel.V(-100, -100), 1)
+	for i, v := range img.Pix {
+		if i%4 != 3 && v != 0 {
+			t.Fatalf("off-screen entity was rendered")
+		}
+	}
+}
+
+func TestSpaceSceneGolden(t *testing.T) {
+	resourceManager := loadTestResources()
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renderer := NewImageRenderer(640, 480, resourceManager)
+	player := NewPlayer("Golden", resourceManager)
+	scene := NewSpaceScene(system, player, renderer, testSeed)
+	scene.starscape = NewSeededStarscape(renderer, scene.camera, 0.2, 1)
+
+	// let the ship's systems scan the scene before rendering
+	scene.tick(1.0 / 60)
+	scene.Render()
+
+	compareGolden(t, "space_scene", renderer.Image())
+}
+
+func TestSystemThumbnailGolden(t *testing.T) {
+	resourceManager := loadTestResources()
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareGolden(t, "vera_thumbnail", SystemThumbnail(system, resourceManager, 160, 160))
+}
+
+func TestFrameRecorder(t *testing.T) {
+	directory, err := ioutil.TempDir("", "frames")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	renderer := NewImageRenderer(32, 32, NewStandardResourceManager("data/resources"))
+	recorder := NewFrameRecorder(directory, 3)
+	recorder.now = func() time.Time {
+		return time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	// nothing is saved unless requested
+	recorder.Frame(renderer)
+
+	recorder.Screenshot()
+	recorder.Frame(renderer)
+
+	recorder.ToggleCapture()
+	for i := 0; i < 7; i++ {
+		recorder.Frame(renderer)
+	}
+	recorder.ToggleCapture()
+	recorder.Frame(renderer)
+	recorder.Wait()
+
+	screenshots, _ := filepath.Glob(filepath.Join(directory, "screenshot-*.png"))
+	if len(screenshots) != 1 {
+		t.Errorf("expected 1 screenshot, found %v", screenshots)
+	}
+
+	// frames 0, 3 and 6 are captured
+	frames, _ := filepath.Glob(filepath.Join(directory, "capture-*", "frame-*.png"))
+	if len(frames) != 3 {
+		t.Errorf("expected 3 captured frames, found %v", frames)
+	}
+}
+
+func TestRenderQueueOrder(t *testing.T) {
+	renderer := NewImageRenderer(32, 32, NewStandardResourceManager("data/resources"))
+	queue := NewRenderQueue(renderer)
+
+	var drawn []string
+	submit := func(layer RenderLayer, depth float64, name string) {
+		queue.Submit(layer, depth, func(Renderer) {
+			drawn = append(drawn, name)
+		})
+	}
+	submit(LayerHUD, 0, "hud")
+	submit(LayerShips, 0, "ship1")
+	submit(LayerBackground, 2.0, "near star")
+	submit(LayerProjectiles, 0, "projectile")
+	submit(LayerShips, 0, "ship2")
+	submit(LayerBackground, 0.5, "far star")
+	submit(LayerCelestials, 0, "planet")
+	submit(LayerEffects, 0, "explosion")
+	queue.Update()
+
+	expected := []string{"far star", "near star", "planet", "ship1", "ship2", "projectile", "explosion", "hud"}
+	if !reflect.DeepEqual(drawn, expected) {
+		t.Errorf("drawn in order %v, expected %v", drawn, expected)
+	}
+
+	// the queue is emptied by flushing
+	drawn = nil
+	queue.Update()
+	if len(drawn) != 0 {
+		t.Errorf("commands were drawn twice: %v", drawn)
+	}
+}
+
+type fixedWidget struct {
+	anchor HUDAnchor
+	size   pixel.Vec
+}
+
+func (fw fixedWidget) Anchor() HUDAnchor                           { return fw.anchor }
+func (fw fixedWidget) Size(Renderer) pixel.Vec                     { return fw.size }
+func (fw fixedWidget) Render(renderer Renderer, bounds pixel.Rect) {}
+
+func TestHUDLayout(t *testing.T) {
+	hud := &HUD{margin: 10, spacing: 5}
+	renderer := NewImageRenderer(400, 300, NewStandardResourceManager("data/resources"))
+	widgets := []HUDWidget{
+		fixedWidget{AnchorTopLeft, pixel.V(100, 20)},
+		fixedWidget{AnchorBottomRight, pixel.V(50, 40)},
+		fixedWidget{AnchorTopLeft, pixel.V(80, 30)},
+		fixedWidget{AnchorBottomRight, pixel.V(60, 10)},
+		fixedWidget{AnchorTopRight, pixel.V(70, 10)},
+		fixedWidget{AnchorBottomLeft, pixel.V(30, 30)},
+	}
+
+	expected := []pixel.Rect{
+		pixel.R(10, 270, 110, 290),
+		pixel.R(340, 10, 390, 50),
+		pixel.R(10, 235, 90, 265),
+		pixel.R(330, 55, 390, 65),
+		pixel.R(320, 280, 390, 290),
+		pixel.R(10, 10, 40, 40),
+	}
+
+	layout := hud.Layout(renderer, widgets)
+	for i := range expected {
+		if layout[i] != expected[i] {
+			t.Errorf("widget %d: laid out at %v, expected %v", i, layout[i], expected[i])
+		}
+	}
+}
+
+type testFaction struct {
+	name         string
+	color        color.Color
+	relationship float64 // with everyone else
+}
+
+func (tf testFaction) Name() string                                    { return tf.name }
+func (tf testFaction) Color() color.Color                              { return tf.color }
+func (tf testFaction) ModifyRelationship(other Faction, delta float64) {}
+func (tf testFaction) Relationship(other Faction) float64              { return tf.relationship }
+
+func TestRadarGolden(t *testing.T) {
+	resourceManager := loadTestResources()
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player := NewShip("Starbridge")
+	pirate := NewShip("Starbridge")
+	pirate.SetFaction(testFaction{"Pirates", colornames.Red, -1})
+	pirate.Translate(pixel.V(-600, 300))
+	trader := NewShip("Starbridge")
+	trader.Translate(pixel.V(200, -900))
+
+	player.Update(SceneInformation{
+		Celestials: system.Celestials(),
+		Entities:   []Entity{player, pirate, trader},
+	})
+	scanner := player.systems["scanner"].(*ShipScanner)
+	scanner.NextTarget()
+	scanner.NextCelestial()
+
+	renderer := NewImageRenderer(200, 200, resourceManager)
+	NewHUD().Render(renderer, []HUDWidget{NewRadarWidget(scanner)})
+	compareGolden(t, "radar", renderer.Image())
+
+	// the system map fills the screen
+	scanner.ToggleSystemMap()
+	renderer.Clear()
+	NewHUD().Render(renderer, []HUDWidget{NewRadarWidget(scanner)})
+	compareGolden(t, "system_map", renderer.Image())
+}
+
+func TestRadarZoom(t *testing.T) {
+	scanner := DefaultShipScanner()
+	scanner.Range = 10000
+
+	var ranges []float64
+	for i := 0; i < 4; i++ {
+		ranges = append(ranges, scanner.RadarRange())
+		scanner.ZoomRadar()
+	}
+
+	// zoom levels beyond the scanner's range are skipped
+	expected := []float64{2000, 8000, 2000, 8000}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("radar ranges %v, expected %v", ranges, expected)
+	}
+}
+
+func TestMarkersGolden(t *testing.T) {
+	resourceManager := loadTestResources()
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renderer := NewImageRenderer(640, 480, resourceManager)
+	player := NewPlayer("Golden", resourceManager)
+	player.Ship().SetFaction(testFaction{"Federation", colornames.Blue, 0})
+	player.Ship().Translate(pixel.V(-400, -300))
+	scene := NewSpaceScene(system, player, renderer, testSeed)
+	scene.starscape = NewSeededStarscape(renderer, scene.camera, 0.2, 1)
+
+	trader := NewShip("Starbridge")
+	trader.Translate(pixel.V(-520, -180))
+	pirate := NewShip("Starbridge")
+	pirate.SetFaction(testFaction{"Pirates", colornames.Red, -1})
+	pirate.Translate(pixel.V(-100, -900))
+	wreck := NewShip("Starbridge")
+	wreck.Translate(pixel.V(-800, -50))
+	scene.entities = append(scene.entities, trader, pirate, wreck)
+	scene.AddObjective(wreck)
+
+	scene.tick(1.0 / 60)
+	scanner := player.Ship().systems["scanner"].(*ShipScanner)
+	for scanner.Target() != trader {
+		scanner.NextTarget()
+	}
+	scanner.NextCelestial()
+
+	markers := scene.Markers()
+	if len(markers) != 4 {
+		t.Errorf("expected markers for the target, celestial, hostile and objective, got %v", markers)
+	}
+
+	scene.Render()
+	compareGolden(t, "markers", renderer.Image())
+}
+
+func TestTextLayout(t *testing.T) {
+	face := basicfont.Face7x13 // 7 pixels per character, 13 per line
+
+	lines := wrapLine(face, "the quick brown fox", 70)
+	if len(lines) != 2 || lines[0] != "the quick" || lines[1] != "brown fox" {
+		t.Errorf("wrapped into %q", lines)
+	}
+
+	layout := layoutText(face, "ab\nabcd", pixel.V(100, 50), TextStyle{Align: AlignRight})
+	if layout[0].dot != pixel.V(86, 50) || layout[1].dot != pixel.V(72, 37) {
+		t.Errorf("right aligned lines start at %v and %v", layout[0].dot, layout[1].dot)
+	}
+	layout = layoutText(face, "abcd", pixel.V(100, 50), TextStyle{Align: AlignCenter})
+	if layout[0].dot != pixel.V(86, 50) {
+		t.Errorf("centered line starts at %v", layout[0].dot)
+	}
+
+	if size := measureText(face, "abc\nab", DefaultTextStyle); size != pixel.V(21, 26) {
+		t.Errorf("measured %v", size)
+	}
+
+	if _, err := loadTestResources().Font("Go-Mono", 12); err != nil {
+		t.Error("Could not load font:", err)
+	}
+}
+
+func TestScaledRenderer(t *testing.T) {
+	renderer := NewImageRenderer(640, 480, NewStandardResourceManager("data/resources"))
+	scaled := NewScaledRenderer(renderer, pixel.V(320, 180))
+
+	// the shorter side decides the scale, the bounds keep the screen's aspect ratio
+	if scale := scaled.Scale(); scale != 2 {
+		t.Errorf("scale is %v", scale)
+	}
+	if bounds := scaled.Bounds(); bounds != pixel.R(0, 0, 320, 240) {
+		t.Errorf("virtual bounds are %v", bounds)
+	}
+
+	scaled.Circle(pixel.V(50, 50), 5, 0, colornames.Red)
+	img := renderer.Image()
+	if c := img.RGBAAt(100, 480-1-100); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("center of the circle is %v", c)
+	}
+	if c := img.RGBAAt(100+8, 480-1-100); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("the circle was not scaled, %v inside its radius", c)
+	}
+}
+
+func TestStarscapeResize(t *testing.T) {
+	renderer := NewImageRenderer(320, 240, loadTestResources())
+	starscape := NewSeededStarscape(renderer, NewChaseCamera(NewShip("Starbridge")), 0.2, 1)
+	before := len(starscape.stars)
+
+	starscape.Resize(pixel.R(0, 0, 640, 480))
+	if after := len(starscape.stars); after < before*3 {
+		t.Errorf("%d stars for four times the area, there were %d", after, before)
+	}
+	for _, star := range starscape.stars {
+		if star.position.X > 640*starscape.scaleFactor || star.position.Y > 480*starscape.scaleFactor {
+			t.Fatalf("star at %v is outside the new screen", star.position)
+		}
+	}
+}
+
+func TestDebugOverlayGolden(t *testing.T) {
+	resourceManager := loadTestResources()
+	system, err := LoadSystem("data/resources/universe/systems/Vera.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renderer := NewImageRenderer(640, 480, resourceManager)
+	player := NewPlayer("Golden", resourceManager)
+	player.Ship().Translate(pixel.V(600, 350))
+	player.Ship().angle = -math.Pi / 4
+	player.Ship().velocity = pixel.V(120, 60)
+	scene := NewSpaceScene(system, player, renderer, testSeed)
+	scene.starscape = NewSeededStarscape(renderer, scene.camera, 0.2, 1)
+
+	trader := NewShip("Starbridge")
+	trader.Translate(pixel.V(520, 420))
+	trader.angle = math.Pi / 2
+	trader.velocity = pixel.V(-60, 0)
+	scene.entities = append(scene.entities, trader)
+
+	escort := NewShip("Starbridge")
+	escort.Translate(pixel.V(480, 440))
+	scene.entities = append(scene.entities, escort)
+
+	scene.debug.Toggle()
+	scene.debug.goroutines = func() int { return 8 }
+	scene.tick(1.0 / 60)
+	scanner := player.Ship().systems["scanner"].(*ShipScanner)
+	scanner.NextTarget()
+	scanner.NextCelestial()
+
+	// the escort bumps into the trader, the step would push them apart
+	escort.Translate(pixel.V(28, -30))
+	scene.contacts = scene.collisions.Detect([]Collider{trader, escort})
+	if len(scene.contacts) != 1 {
+		t.Fatalf("%d contacts between the trader and its escort", len(scene.contacts))
+	}
+
+	// a few slow frames in the graph
+	for i := 0; i < debugFrames; i++ {
+		dt := 1.0 / 60
+		if i%30 == 0 {
+			dt = 1.0 / 40
+		}
+		scene.debug.Frame(dt)
+	}
+
+	scene.Render()
+	compareGolden(t, "debug_overlay", renderer.Image())
+}
+
+func TestImageRendererClip(t *testing.T) {
+	renderer := NewImageRenderer(64, 48, NewStandardResourceManager("data/resources"))
+	red := color.RGBA{255, 0, 0, 255}
+
+	renderer.Clip(pixel.R(10, 8, 30, 28), func(clipped Renderer) {
+		if bounds := clipped.Bounds(); bounds != pixel.R(0, 0, 20, 20) {
+			t.Errorf("clipped bounds are %v", bounds)
+		}
+		// a circle much larger than the clipped area
+		clipped.Circle(clipped.Center(), 100, 0, red)
+
+		captured, _ := clipped.Capture()
+		if size := captured.Bounds().Size(); size != image.Pt(20, 20) || captured.RGBAAt(0, 0) != red {
+			t.Errorf("captured %v of clipped area, %v at its origin", size, captured.RGBAAt(0, 0))
+		}
+	})
+
+	img := renderer.Image()
+	for _, check := range []struct {
+		x, y   int // pixel coordinates, from the bottom left
+		inside bool
+	}{{10, 8, true}, {29, 27, true}, {9, 8, false}, {10, 7, false}, {30, 20, false}, {20, 28, false}} {
+		c := img.RGBAAt(check.x, 48-1-check.y)
+		if (c == red) != check.inside {
+			t.Errorf("pixel %d, %d is %v", check.x, check.y, c)
+		}
+	}
+}
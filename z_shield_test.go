@@ -0,0 +1,137 @@
+package spacegame
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func TestShieldsArmorAndHull(t *testing.T) {
+	ship := NewShip("Test")
+	ship.armor = 20
+	shield := &ShipShield{Capacity: 50, Recharge: 10, Delay: 2}
+	ship.systems["shield"] = shield
+	shield.Install(ship)
+
+	// the shield takes it all while it lasts
+	ship.Damage(30)
+	if shields, _ := ship.Shields(); shields != 20 {
+		t.Errorf("%v shields left after 30 damage to 50", shields)
+	}
+	if hull, max := ship.Hull(); hull != max {
+		t.Errorf("the hull took %v damage through the shields", max-hull)
+	}
+
+	// then the armor stops a fifth of what gets through
+	ship.Damage(30)
+	if hull, max := ship.Hull(); max-hull != 8 {
+		t.Errorf("the hull took %v damage, 10 got through armor of 20%%", max-hull)
+	}
+
+	// the shield waits for the fighting to stop before it recharges
+	update := func(duration float64) {
+		for elapsed := 0.0; elapsed < duration-1e-9; elapsed += 1.0 / 60 {
+			shield.Update(SceneInformation{Elapsed: 1.0 / 60})
+		}
+	}
+	update(1.5)
+	if shields, _ := ship.Shields(); shields != 0 {
+		t.Errorf("the shield recharged to %v during its delay", shields)
+	}
+	update(1.5)
+	if shields, _ := ship.Shields(); math.Abs(shields-10) > 1 {
+		t.Errorf("the shield recharged to %v a second after its delay, at 10 a second", shields)
+	}
+	update(10)
+	if shields, max := ship.Shields(); shields != max {
+		t.Errorf("the shield recharged to %v of %v", shields, max)
+	}
+
+	// all of it is on the HUD
+	lines := strings.Join(ship.condition(), "\n")
+	for _, expected := range []string{"Shields:  [##########]   50", "Armor:    20%", "Hull:     [#########-]   92"} {
+		if !strings.Contains(lines, expected) {
+			t.Errorf("%q isn't on the HUD:\n%s", expected, lines)
+		}
+	}
+}
+
+func TestShipDestroyed(t *testing.T) {
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Range"), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+	shooter := player.Ship()
+	target := NewShip("Target")
+	target.Translate(pixel.V(0, 300))
+	target.hull = 15
+	scene.entities = append(scene.entities, target)
+	scene.tick(1.0 / 60)
+	scanner := shooter.systems["scanner"].(*ShipScanner)
+	scanner.NextTarget()
+
+	// the target panel shows how the target is holding up
+	var panel []string
+	for _, widget := range scanner.Widgets() {
+		if text, ok := widget.(*TextWidget); ok {
+			panel = append(panel, text.lines()...)
+		}
+	}
+	if !strings.Contains(strings.Join(panel, "\n"), "  Hull:     [##--------]   15") {
+		t.Errorf("the target's hull isn't on the target panel:\n%s", strings.Join(panel, "\n"))
+	}
+
+	// two shots take it apart
+	weapon := shooter.systems["weapon"].(*ShipWeapon)
+	weapon.Spread = 0
+	for i := 0; i < 60 && len(scene.entities) == 2; i++ {
+		shooter.Process(pilotAction{actionFire, 1.0 / 60})
+		scene.tick(1.0 / 60)
+	}
+	if len(scene.entities) != 1 || scene.entities[0] != Entity(shooter) {
+		t.Fatalf("%d entities left in the scene, the target has %v hull", len(scene.entities), target.hull)
+	}
+	if !target.Destroyed() || scene.particles.Count() == 0 {
+		t.Errorf("the target went without an explosion")
+	}
+	scene.tick(1.0 / 60)
+	if scanner.Target() != nil {
+		t.Errorf("the scanner is still targeting %v", scanner.Target())
+	}
+}
+
+func TestPlayerDestroyed(t *testing.T) {
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Range"), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+	ship := player.Ship()
+	enemy := NewShip("Target")
+	enemy.Translate(pixel.V(0, 300))
+	scene.entities = append(scene.entities, enemy)
+	scene.AddObjective(enemy)
+	ship.velocity = pixel.V(30, 0)
+	scene.tick(1.0 / 60)
+
+	ship.Damage(1e6)
+	scene.tick(1.0 / 60)
+	if len(scene.entities) != 1 || scene.entities[0] != Entity(enemy) || !scene.gameOver {
+		t.Fatalf("%d entities left in the scene after the player's ship was destroyed", len(scene.entities))
+	}
+	if scene.particles.Count() == 0 {
+		t.Error("the player's ship went without an explosion")
+	}
+
+	// the wreck doesn't fly anymore, and nothing points at where the player would be going
+	wreck := ship.Coordinates()
+	for i := 0; i < 60; i++ {
+		ship.Process(pilotAction{actionAccel, 1.0 / 60})
+		scene.Aim(pixel.V(10, 10))
+		scene.tick(1.0 / 60)
+	}
+	if ship.Coordinates() != wreck || scene.camera.Motion() != pixel.ZV {
+		t.Errorf("the wreck moved from %v to %v", wreck, ship.Coordinates())
+	}
+	if markers := scene.Markers(); len(markers) != 0 {
+		t.Errorf("%d markers on the HUD after the game is over", len(markers))
+	}
+	scene.Render()
+}
@@ -0,0 +1,147 @@
+package spacegame
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+func testTurret(ship *Ship, turret *ShipTurret) *ShipTurret {
+	ship.systems["turret"] = turret
+	turret.Install(ship)
+	return turret
+}
+
+// update the turret for duration seconds, holding the trigger if fire is set
+func turnTurret(turret *ShipTurret, pool *ProjectilePool, duration float64, fire bool) {
+	for elapsed := 0.0; elapsed < duration-1e-9; elapsed += 1.0 / 60 {
+		if fire {
+			turret.Activate(pilotAction{actionFire, 1.0 / 60})
+		}
+		turret.Update(SceneInformation{Projectiles: pool, Elapsed: 1.0 / 60})
+	}
+}
+
+func TestTurretArc(t *testing.T) {
+	ship := NewShip("Starbridge")
+	turret := testTurret(ship, &ShipTurret{Mount: pixel.V(0, 10), Arc: 1, TurnSpeed: 2})
+	turret.SetMode(TurretMouse)
+
+	// behind and to the right is out of the arc, the turret stops at its end
+	turret.Aim(pixel.V(100, -100))
+	turnTurret(turret, nil, 0.25, false)
+	if math.Abs(turret.offset+0.5) > 1e-9 {
+		t.Errorf("the turret turned %v in a quarter second, at 2 a second", turret.offset)
+	}
+	turnTurret(turret, nil, 1, false)
+	if turret.offset != -1 {
+		t.Errorf("the turret turned %v, its arc is 1", turret.offset)
+	}
+
+	// to get to the left it turns back through the front
+	turret.Aim(pixel.V(-100, -90))
+	turnTurret(turret, nil, 0.25, false)
+	if math.Abs(turret.offset+0.5) > 1e-9 {
+		t.Errorf("the turret turned to %v on its way to the other end", turret.offset)
+	}
+
+	// a turret that turns all the way round takes the short way, through the back
+	round := testTurret(ship, &ShipTurret{Arc: math.Pi, TurnSpeed: 2})
+	round.offset = -3
+	round.SetMode(TurretMouse)
+	round.Aim(pixel.V(-10, -100))
+	turnTurret(round, nil, 0.25, false)
+	if round.offset < 2 {
+		t.Errorf("the turret turned to %v, instead of round the back", round.offset)
+	}
+}
+
+func TestTurretFiring(t *testing.T) {
+	ship := NewShip("Starbridge")
+	ship.angle = math.Pi / 2 // pointing left
+	pool := NewProjectilePool(64)
+	turret := testTurret(ship, &ShipTurret{
+		ShipWeapon: ShipWeapon{FireRate: 10, ProjectileSpeed: 500, Lifetime: 1},
+		Mount:      pixel.V(8, 0),
+		Facing:     math.Pi / 2,
+		Arc:        2,
+		TurnSpeed:  4,
+	})
+
+	// at rest, the turret on the right points out of the left of the hull, which is down
+	if math.Abs(normalizeAngle(turret.Angle()-math.Pi)) > 1e-9 || turret.Position().Sub(pixel.V(0, 8)).Len() > 1e-9 {
+		t.Errorf("the turret is at %v pointing %v", turret.Position(), turret.Angle())
+	}
+
+	// the shots come out of the barrel, the way it points
+	turret.SetMode(TurretMouse)
+	turret.Aim(pixel.V(100, 8))
+	turnTurret(turret, pool, 1, true)
+	if pool.Count() < 9 {
+		t.Fatalf("the turret fired %d shots in a second, at 10 a second", pool.Count())
+	}
+	last := pool.Projectiles()[pool.Count()-1]
+	muzzle := pixel.V(turretSize/2, 8)
+	if last.Coordinates().Sub(muzzle).Len() > 1e-9 || math.Abs(last.Velocity().Angle()) > 1e-9 {
+		t.Errorf("a shot went out from %v at %v, the barrel is at %v pointing right", last.Coordinates(), last.Velocity(), muzzle)
+	}
+}
+
+func TestTurretTracking(t *testing.T) {
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Range"), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+	ship := player.Ship()
+	enemy := NewShip("Target")
+	enemy.Translate(pixel.V(200, 50))
+	scene.entities = append(scene.entities, enemy)
+	scene.tick(1.0 / 60)
+	ship.systems["scanner"].(*ShipScanner).NextTarget()
+
+	// both turrets follow the target
+	for i := 0; i < 60; i++ {
+		scene.tick(1.0 / 60)
+	}
+	turrets := ship.turrets()
+	if len(turrets) != 2 {
+		t.Fatalf("the Starbridge has %d turrets", len(turrets))
+	}
+	for _, turret := range turrets {
+		direction := enemy.Coordinates().Sub(turret.Position()).Angle() - math.Pi/2
+		if math.Abs(normalizeAngle(turret.Angle()-direction)) > 1e-6 {
+			t.Errorf("a turret points %v, the target is at %v", turret.Angle(), direction)
+		}
+	}
+
+	// switched over, they follow the mouse, here up and to the left of the ship
+	ship.Process(pilotAction{actionTurretMode, 1.0 / 60})
+	scene.Aim(scene.renderer.Center().Add(pixel.V(-20, 20)))
+	for i := 0; i < 60; i++ {
+		scene.tick(1.0 / 60)
+	}
+	for _, turret := range turrets {
+		if turret.Mode() != TurretMouse {
+			t.Errorf("the turret is in %v mode", turret.Mode())
+		}
+		if angle := turret.Angle(); angle < 0 || angle > math.Pi/2 {
+			t.Errorf("a turret points %v, at the mouse up and to the left", angle)
+		}
+	}
+}
+
+func TestTurretGolden(t *testing.T) {
+	ship, err := LoadShip("data/resources/entities/ships/Starbridge.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	turrets := ship.turrets()
+	turrets[0].offset, turrets[1].offset = 1, -0.5
+
+	renderer := NewImageRenderer(96, 96, loadTestResources())
+	queue := NewRenderQueue(renderer)
+	camera := NewChaseCamera(ship)
+	camera.zoom = 2
+	ship.Submit(queue, camera)
+	queue.Flush()
+	compareGolden(t, "turrets", renderer.Image())
+}
@@ -0,0 +1,178 @@
+package spacegame
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/pixel"
+)
+
+// hold the trigger of a weapon for duration seconds
+func holdTrigger(weapon *ShipWeapon, pool *ProjectilePool, duration float64) {
+	for elapsed := 0.0; elapsed < duration-1e-9; elapsed += 1.0 / 60 {
+		weapon.Activate(pilotAction{actionFire, 1.0 / 60})
+		weapon.Update(SceneInformation{Projectiles: pool, Elapsed: 1.0 / 60})
+	}
+}
+
+func testWeapon(ship *Ship, weapon *ShipWeapon) *ShipWeapon {
+	ship.systems["weapon"] = weapon
+	weapon.Install(ship)
+	return weapon
+}
+
+func TestWeaponFiring(t *testing.T) {
+	ship := NewShip("Starbridge")
+	ship.velocity = pixel.V(50, 0)
+	ship.angle = math.Pi / 2 // pointing left
+	pool := NewProjectilePool(64)
+	weapon := testWeapon(ship, &ShipWeapon{Damage: 5, FireRate: 10, ProjectileSpeed: 500, Lifetime: 1, Spread: 0.1})
+
+	holdTrigger(weapon, pool, 1)
+	if count := pool.Count(); count < 10 || count > 11 {
+		t.Errorf("%d shots in a second, at 10 a second", count)
+	}
+	for _, projectile := range pool.Projectiles() {
+		// the shots keep the ship's velocity
+		own := projectile.Velocity().Sub(ship.Velocity())
+		if math.Abs(own.Len()-500) > 1e-9 || math.Abs(normalizeAngle(own.Angle()-math.Pi)) > 0.1+1e-9 {
+			t.Errorf("a shot went out at %v", own)
+		}
+	}
+
+	// letting go doesn't save up shots
+	weapon.Update(SceneInformation{Projectiles: pool, Elapsed: 5})
+	before := pool.Count()
+	holdTrigger(weapon, pool, 1.0/60)
+	if fired := pool.Count() - before; fired != 1 {
+		t.Errorf("%d shots at once after waiting", fired)
+	}
+
+	// they fizzle out
+	for i := 0; i < 60; i++ {
+		pool.Step(1.0 / 60)
+	}
+	if pool.Count() != 0 {
+		t.Errorf("%d projectiles are still flying after their lifetime", pool.Count())
+	}
+}
+
+func TestWeaponSpread(t *testing.T) {
+	// a weapon spreads its shots from the scene's seed
+	volley := func(seed int64) []pixel.Vec {
+		ship := NewShip("Starbridge")
+		pool := NewProjectilePool(64)
+		weapon := testWeapon(ship, &ShipWeapon{FireRate: 10, ProjectileSpeed: 500, Lifetime: 1, Spread: 0.2})
+		for i := 0; i < 60; i++ {
+			weapon.Activate(pilotAction{actionFire, 1.0 / 60})
+			weapon.Update(SceneInformation{Projectiles: pool, Elapsed: 1.0 / 60, Seed: seed})
+		}
+		var shots []pixel.Vec
+		for _, projectile := range pool.Projectiles() {
+			shots = append(shots, projectile.Velocity())
+		}
+		return shots
+	}
+
+	shots, again := volley(testSeed), volley(testSeed)
+	if len(again) != len(shots) {
+		t.Fatalf("%d shots, and %d with the same seed", len(shots), len(again))
+	}
+	spread := 0.0
+	for i, shot := range shots {
+		// ships point up at angle 0
+		angle := normalizeAngle(shot.Angle() - math.Pi/2)
+		if math.Abs(angle) > 0.2+1e-9 {
+			t.Errorf("a shot went out %v off the heading, the spread is 0.2", angle)
+		}
+		spread = math.Max(spread, math.Abs(angle))
+		if again[i] != shot {
+			t.Errorf("shot %d went out at %v, and at %v with the same seed", i, shot, again[i])
+		}
+	}
+	if len(shots) < 10 || spread < 0.05 {
+		t.Errorf("%d shots spread at most %v", len(shots), spread)
+	}
+	if other := volley(testSeed + 1); other[0] == shots[0] && other[1] == shots[1] {
+		t.Errorf("another seed spread the shots the same way")
+	}
+}
+
+func TestWeaponSeeds(t *testing.T) {
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Range"), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+	wingman, err := LoadShip("data/resources/entities/ships/Starbridge.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spare := &ShipWeapon{FireRate: 10, ProjectileSpeed: 500, Lifetime: 1, Spread: 0.2}
+	player.Ship().systems["spare weapon"] = spare
+	spare.Install(player.Ship())
+	scene.entities = append(scene.entities, wingman)
+	scene.tick(1.0 / 60)
+
+	// the same gun on two ships, and two guns on one, all spread their shots their own way
+	seen := map[int64]bool{}
+	for _, gun := range []*ShipWeapon{player.Ship().systems["weapon"].(*ShipWeapon), wingman.systems["weapon"].(*ShipWeapon), spare} {
+		first := gun.rng.Int63()
+		if seen[first] {
+			t.Errorf("two guns spread their shots the same way")
+		}
+		seen[first] = true
+	}
+}
+
+func TestWeaponCosts(t *testing.T) {
+	pool := NewProjectilePool(64)
+	gun := testWeapon(NewShip("Starbridge"), &ShipWeapon{FireRate: 60, ProjectileSpeed: 500, Lifetime: 1, EnergyCost: 5, Capacity: 20, Recharge: 10})
+	holdTrigger(gun, pool, 1)
+	// a full capacitor, and what it recharged while firing
+	if count := pool.Count(); count != 5 && count != 6 {
+		t.Errorf("%d shots out of a capacitor for 4, recharging 2 a second", count)
+	}
+
+	pool = NewProjectilePool(64)
+	cannon := testWeapon(NewShip("Starbridge"), &ShipWeapon{FireRate: 60, ProjectileSpeed: 500, Lifetime: 1, AmmoCost: 1, Ammo: 3})
+	holdTrigger(cannon, pool, 1)
+	if pool.Count() != 3 || cannon.Ammo != 0 {
+		t.Errorf("%d shots out of 3 rounds, %d left", pool.Count(), cannon.Ammo)
+	}
+}
+
+func TestProjectileHits(t *testing.T) {
+	player := NewPlayer("Test", loadTestResources())
+	scene := NewSpaceScene(NewSolarSystem("Range"), player, NewImageRenderer(64, 64, loadTestResources()), testSeed)
+	shooter := player.Ship()
+	target := NewShip("Target")
+	target.Translate(pixel.V(0, 300))
+	scene.entities = append(scene.entities, target)
+
+	weapon := shooter.systems["weapon"].(*ShipWeapon)
+	weapon.Spread = 0
+	// only the weapon in the nose, the turrets would hit too
+	for name, sys := range shooter.systems {
+		if _, ok := sys.(*ShipTurret); ok {
+			delete(shooter.systems, name)
+		}
+	}
+	for i := 0; i < 60; i++ {
+		shooter.Process(pilotAction{actionFire, 1.0 / 60})
+		scene.tick(1.0 / 60)
+	}
+	// the last shots are still on their way
+	for i := 0; i < 60; i++ {
+		scene.tick(1.0 / 60)
+	}
+
+	hull, max := target.Hull()
+	hits := (max - hull) / weapon.Damage
+	if hits < 5 || hits != math.Round(hits) {
+		t.Errorf("the target took %v damage, from shots doing %v", max-hull, weapon.Damage)
+	}
+	if hull, max := shooter.Hull(); hull != max {
+		t.Errorf("the shooter shot itself, %v of %v hull left", hull, max)
+	}
+	if count := scene.projectiles.Count(); count != 0 {
+		t.Errorf("%d projectiles flew through the target", count)
+	}
+}